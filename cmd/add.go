@@ -1,12 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"net/url"
 
-	"github.com/dikeert/linkman/links"
-	"github.com/dikeert/linkman/pages"
-	"github.com/dikeert/linkman/urls"
+	"github.com/dikeert/linkman/linkman"
 
 	"github.com/spf13/cobra"
 )
@@ -18,93 +16,81 @@ var addCmd = &cobra.Command{
 create new link for supplied URLs
 
 You can provide list you want to use to store URL,
-by default add will use 'default' list.
+by default add will use 'default' list or the one
+set in configuration.
+
+Links added without --list are routed by rules from settings,
+see 'linkman rules --help'.
 
 By default it does not allow to create links for URLs that already
-had links created for them.
+had links created for them, such URLs are skipped. Use --strict
+to exit with code 5 when some URLs are skipped.
+
+Use --priority to mark important links, 'linkman list --sort
+priority -r' shows them first.
 `,
 	Args: cobra.MinimumNArgs(1),
-	Run:  runAdd,
+	RunE: runAdd,
 }
 
 var skipFetchingTitle = false
 var allowDuplicates = false
 var targetList = "default"
 var providedTitle = ""
+var addPriority = 0
+var addStrict = false
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
 
-func runAdd(cmd *cobra.Command, args []string) {
-	if store, err := links.OpenStore(dataPath); err == nil {
-		for _, rawurl := range args {
-			saveRawURL(store, rawurl)
-		}
-	} else {
-		die("Unable to open links store", err)
+	client := newClient(store)
+	var opts []linkman.AddOption
+	if cmd.Flags().Changed("list") {
+		opts = append(opts, linkman.InList(targetList))
 	}
-}
 
-func saveRawURL(store links.Store, rawurl string) {
-	url := parseURL(rawurl)
-	if allowDuplicates || !urlExists(store, url) {
-		saveURL(store, url)
-	} else {
-		fmt.Printf("URL %s already exists, skipping\n", rawurl)
+	if skipFetchingTitle {
+		opts = append(opts, linkman.SkipFetch())
 	}
-}
 
-func parseURL(rawurl string) *url.URL {
-	url, err := urls.ParseURL(rawurl)
-	if err == nil {
-		return url
+	if providedTitle != "" {
+		opts = append(opts, linkman.WithTitle(providedTitle))
 	}
 
-	die("Unable to add URL", err)
-	panic("shouldn't get here")
-}
+	if addPriority != 0 {
+		opts = append(opts, linkman.WithPriority(addPriority))
+	}
 
-func urlExists(store links.Store, url *url.URL) bool {
-	exists, err := store.LinkExists(url)
-	if err == nil {
-		return exists
+	if allowDuplicates {
+		opts = append(opts, linkman.AllowDuplicate())
 	}
 
-	die("Unexpected error", err)
-	panic("shouldn't get there")
-}
+	skipped := 0
+	for _, rawurl := range args {
+		link, err := client.Add(ctx, rawurl, opts...)
+		if errors.Is(err, ErrDuplicate) {
+			fmt.Printf("URL %s already exists, skipping\n", rawurl)
+			skipped++
+			continue
+		} else if err != nil {
+			return fail("Unable to add URL", err)
+		}
 
-func saveURL(store links.Store, url *url.URL) {
-	source := getSource(url)
-	title := fetchTitle(url)
-	link := store.NewLink(url, source, title, targetList)
-	if err := store.SaveLink(link); err == nil {
 		fmt.Println("Create link: ")
 		fmt.Printf("  URL: %s\n", link.URL)
 		fmt.Printf("  Title: %s\n", link.Title)
 		fmt.Printf("  List: %s\n", link.List)
-	} else {
-		die("Unable to save link", err)
-	}
-}
-
-func getSource(url *url.URL) string {
-	source, err := urls.GetSource(url)
-	if err == nil {
-		return source
 	}
 
-	die("Mailformed URL", err)
-	panic("shouldn't get here")
-}
-
-func fetchTitle(url *url.URL) string {
-	if skipFetchingTitle {
-		return providedTitle
-	} else if title, err := pages.FetchTitle(url); err == nil {
-		return title
-	} else {
-		die("Unable to fetch page title", err)
+	if skipped > 0 && addStrict {
+		return failWith(ErrDuplicate, fmt.Sprintf("Skipped %d URLs", skipped), ErrDuplicate)
 	}
 
-	panic("shouldn't get there")
+	return nil
 }
 
 func init() {
@@ -116,4 +102,8 @@ func init() {
 	addCmd.Flags().BoolVarP(&allowDuplicates, "force", "f", false,
 		"Allow duplicates")
 	addCmd.Flags().StringVarP(&targetList, "list", "l", "default", "Target list")
+	addCmd.Flags().BoolVarP(&addStrict, "strict", "", false,
+		"Fail when URLs already exist")
+	addCmd.Flags().IntVarP(&addPriority, "priority", "p", 0,
+		"Priority of the link, from 0 to 3")
 }
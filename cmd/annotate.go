@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate id text...",
+	Short: "Adds annotation to a link",
+	Long: `'annotate' adds a timestamped annotation to the link,
+e.g. a highlight or a remark. Words of the text are joined
+with spaces, so quotes are optional.
+
+Use --show to print annotations of the link instead. Annotations
+are searched by 'search' and available to templates as
+{{range .Annotations}}{{.Created | date "2006-01-02"}} {{.Text}}{{end}}
+
+Examples:
+
+linkman annotate 42 "Bolt keeps the whole database in a single file"
+linkman annotate 42 --show
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAnnotate,
+}
+
+var annotateShow = false
+
+func runAnnotate(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	ids, err := parseIDs(args[:1])
+	if err != nil {
+		return err
+	}
+
+	text := strings.TrimSpace(strings.Join(args[1:], " "))
+	if text == "" && !annotateShow {
+		return fail("Unable to annotate link", fmt.Errorf("no text given"))
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	if annotateShow {
+		link, err := findLink(ctx, store, ids[0])
+		if err != nil {
+			return err
+		}
+
+		for _, annotation := range link.Annotations {
+			fmt.Printf("%s  %s\n", annotation.Created.Format("2006-01-02 15:04"), annotation.Text)
+		}
+		return nil
+	}
+
+	return editLinks(ctx, store, ids, "Annotated", func(link *links.Link) {
+		link.Annotate(text)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+
+	annotateCmd.Flags().BoolVarP(&annotateShow,
+		"show", "s", false,
+		"Print annotations of the link")
+}
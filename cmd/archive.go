@@ -1,9 +1,8 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-	"strconv"
+	"context"
+	"time"
 
 	"github.com/dikeert/linkman/links"
 	"github.com/spf13/cobra"
@@ -11,44 +10,34 @@ import (
 
 // archiveCmd represents the archive command
 var archiveCmd = &cobra.Command{
-	Use:   "archive id [other ids]",
+	Use:   "archive [id|@saved-search]...",
 	Short: "archives a link",
 	Long: `arhives link with specified ID.
-
+` + bulkUsage + `
 Example:
 
 linkman archive id
+linkman archive @saved-search
+linkman archive -l watch -s youtube --dry-run
+linkman archive -l watch -s youtube --yes
 `,
-	Args: cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		store := openStore(dataPath)
-
-		for _, idArg := range args {
-			if id, err := strconv.Atoi(idArg); err == nil {
-				archiveLink(store, id)
-			} else {
-				fmt.Fprintf(os.Stderr, "Value %s is not an ID", idArg)
-			}
-		}
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBulk(cmd, args, archiveFlags, "archive", "Archived", archiveLinks)
 	},
 }
 
-func openStore(path string) links.Store {
-	store, err := links.OpenStore(path)
-	if err == nil {
-		return store
-	}
+var archiveFlags = &bulkFlags{}
 
-	die("Unable to open store", err)
-	panic("shouldn't get there")
-}
-
-func archiveLink(store links.Store, id int) {
-	if err := store.ArchiveByID(id); err != nil {
-		die("Unable to archive link", err)
-	}
+func archiveLinks(ctx context.Context, store links.Store, filter links.LinkFilter) ([]links.Link, error) {
+	return store.UpdateLinks(ctx, filter, func(link *links.Link) {
+		if !link.Archived {
+			link.Archived = true
+			link.ArchivedAt = time.Now()
+		}
+	})
 }
 
 func init() {
 	rootCmd.AddCommand(archiveCmd)
+	archiveFlags.register(archiveCmd.Flags())
 }
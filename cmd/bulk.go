@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+//bulkFlags holds flags of the commands that change
+//links in bulk: links are selected either by IDs,
+//saved searches or the same filtering flags 'list' has.
+type bulkFlags struct {
+	filterFlags
+	yes    bool
+	dryRun bool
+}
+
+//bulkTarget is a set of links a bulk command changes at once.
+type bulkTarget struct {
+	filter links.LinkFilter
+	ids    []int
+	//confirm tells whether the user should confirm
+	//the change, as opposed to links picked by ID.
+	confirm bool
+}
+
+//bulkAction changes links allowed by the filter in
+//a single transaction and returns changed links.
+type bulkAction func(ctx context.Context, store links.Store, filter links.LinkFilter) ([]links.Link, error)
+
+const bulkUsage = `
+Links are selected by IDs, saved searches (@name) or, when
+neither is given, by the same filtering flags 'list' command
+has. Changing links selected by flags or saved search requires
+confirmation, use --yes to skip it and --dry-run to only print
+links that would be changed.
+`
+
+func (me *bulkFlags) register(flags *pflag.FlagSet) {
+	me.filterFlags.register(flags)
+
+	flags.BoolVarP(&me.yes,
+		"yes", "y", false,
+		"Do not ask for confirmation")
+
+	flags.BoolVarP(&me.dryRun,
+		"dry-run", "", false,
+		"Only print links that would be changed")
+}
+
+//targets turns arguments and flags into sets of links to change.
+func (me *bulkFlags) targets(cmd *cobra.Command,
+	store links.Store,
+	args []string) ([]bulkTarget, error) {
+	ctx := commandContext(cmd)
+
+	var targets []bulkTarget
+	var ids []int
+	flags := cmd.Flags()
+
+	for _, arg := range args {
+		if isSavedSearchRef(arg) {
+			saved, err := findSavedSearch(ctx, store, arg)
+			if err != nil {
+				return nil, err
+			}
+
+			filter, err := me.filter(flags, saved)
+			if err != nil {
+				return nil, err
+			}
+
+			targets = append(targets, bulkTarget{filter: filter, confirm: true})
+		} else if id, err := strconv.Atoi(arg); err == nil {
+			ids = append(ids, id)
+		} else {
+			fmt.Fprintf(os.Stderr, "Value %s is not an ID\n", arg)
+		}
+	}
+
+	if len(ids) > 0 {
+		conds, err := me.conditions(flags, true)
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, bulkTarget{
+			filter: links.NewFilter(append(conds, links.WithIDs(ids...))...),
+			ids:    ids,
+		})
+	}
+
+	if len(args) == 0 {
+		if !me.changed(flags) {
+			return nil, fail("Nothing to change",
+				fmt.Errorf("specify IDs, saved search or filtering flags"))
+		}
+
+		filter, err := me.filter(flags, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, bulkTarget{filter: filter, confirm: true})
+	}
+
+	return targets, nil
+}
+
+//runBulk applies action to links selected by arguments and flags,
+//verb and done describe the action in messages, e.g. archive, Archived.
+//When some of the links selected by IDs don't exist, ErrNotFound
+//is returned after the rest are changed.
+func runBulk(cmd *cobra.Command,
+	args []string,
+	opts *bulkFlags,
+	verb string,
+	done string,
+	action bulkAction) error {
+	ctx := commandContext(cmd)
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	targets, err := opts.targets(cmd, store, args)
+	if err != nil {
+		return err
+	}
+
+	var missing error
+	for _, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if opts.dryRun || (target.confirm && !opts.yes) {
+			found, err := store.FindLinks(ctx, target.filter)
+			if err != nil {
+				return fail("Unable to fetch links", err)
+			}
+
+			if opts.dryRun {
+				printAffected(fmt.Sprintf("Would %s", verb), found)
+				continue
+			}
+
+			if len(found) == 0 {
+				continue
+			}
+
+			if !confirm(ctx, verb, found) {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		changed, err := action(ctx, store, target.filter)
+		if err != nil {
+			return fail(fmt.Sprintf("Unable to %s links", verb), err)
+		}
+
+		if err := reportMissing(target.ids, changed); err != nil {
+			missing = fail(fmt.Sprintf("Unable to %s links", verb), err)
+		}
+		fmt.Printf("%s %d links\n", done, len(changed))
+	}
+
+	return missing
+}
+
+func printAffected(header string, found []links.Link) {
+	fmt.Printf("%s %d links:\n", header, len(found))
+	writer := getOutputWriter()
+	for _, link := range found {
+		fmt.Fprintf(writer, "  %d\t%s\t%s\n", link.ID, link.Title, link.URL)
+	}
+	writer.Flush()
+}
+
+//confirm asks user whether to go on, it gives up when ctx is done.
+func confirm(ctx context.Context, verb string, found []links.Link) bool {
+	printAffected(fmt.Sprintf("About to %s", verb), found)
+	fmt.Print("Continue? [y/N] ")
+
+	answers := make(chan string, 1)
+	go func() {
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answers <- answer
+	}()
+
+	select {
+	case answer := <-answers:
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes"
+	case <-ctx.Done():
+		fmt.Println()
+		return false
+	}
+}
+
+//reportMissing returns ErrNotFound listing IDs
+//of the links that weren't changed, if there are any.
+func reportMissing(ids []int, changed []links.Link) error {
+	found := map[int]bool{}
+	for _, link := range changed {
+		found[link.ID] = true
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, strconv.Itoa(id))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("links with IDs %s %w", strings.Join(missing, ", "), ErrNotFound)
+	}
+
+	return nil
+}
@@ -1,14 +1,23 @@
 package cmd_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	neturl "net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/dikeert/linkman/cmd"
 	"github.com/dikeert/linkman/links"
+	"github.com/dikeert/linkman/tui"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -24,6 +33,28 @@ func TestCommands(t *testing.T) {
 		AddWithCustomTitle,
 		AddNoDuplcatesByDef,
 		AddForceDuplicate,
+		SearchByTitle,
+		SearchByPageText,
+		FilterByTitle,
+		FilterByQuery,
+		SortAndPage,
+		ArchiveSavedSearch,
+		BulkByFilter,
+		CountAndStats,
+		TypedErrors,
+		CancelledCommands,
+		ConfigDefaults,
+		ProfilesAndDB,
+		NamedFormats,
+		OpenLinks,
+		PickExternally,
+		NextAndRandom,
+		PriorityAndRating,
+		NotesAndAnnotations,
+		SnoozeAndDue,
+		RetentionPolicies,
+		RoutingRules,
+		SourceRules,
 	}
 
 	for _, tc := range tests {
@@ -32,7 +63,7 @@ func TestCommands(t *testing.T) {
 			path := getDataFile()
 			defer os.Remove(path)
 
-			if store, err := links.OpenStore(path); err == nil {
+			if store, err := links.OpenStore(context.Background(), path); err == nil {
 				tc(path, store, t)
 			} else {
 				t.Error(err)
@@ -143,8 +174,708 @@ func AddForceDuplicate(path string, store links.Store, t *testing.T) {
 	}
 }
 
+func SearchByTitle(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+
+	cmd.Execute(path, []string{
+		"add",
+		url,
+		"--skip-title-fetch",
+		"-t", "Bolt transactions explained",
+	})
+
+	cmd.Execute(path, []string{
+		"add",
+		"https://example.com/",
+		"--skip-title-fetch",
+		"-t", "Unrelated page",
+	})
+
+	results, err := store.Search(context.Background(), "transactions", links.NewFilter(
+		links.FromList("*"),
+	))
+
+	if err == nil {
+		assert.Equal(1, len(results), "Should find one link")
+		assert.Equal(url, results[0].Link.URL.String(),
+			"Should find link by word in title")
+	} else {
+		t.Error(err)
+	}
+}
+
+func SearchByPageText(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	u, _ := neturl.Parse(url)
+	link := store.NewLink(u, "wikipedia", "Bolt", "default")
+	link.Text = "Čeština in buckets of pages"
+	if err := store.SaveLink(ctx, link); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.FindLinks(ctx, links.NewFilter())
+	if assert.NoError(err) && assert.Equal(1, len(found)) {
+		assert.Empty(found[0].Text, "Should leave page text out")
+	}
+
+	_, err = store.UpdateLinks(ctx, links.NewFilter(), func(link *links.Link) {
+		link.AddTags("kept")
+	})
+	assert.NoError(err)
+
+	results, err := store.Search(ctx, "buckets", links.NewFilter())
+	if assert.NoError(err) && assert.Equal(1, len(results), "Should find link by page text") {
+		assert.Equal(link.Text, results[0].Link.Text, "Should keep page text")
+	}
+
+	_, err = store.DeleteLinks(ctx, links.NewFilter())
+	assert.NoError(err)
+	assert.NoError(store.Reindex(ctx))
+
+	results, err = store.Search(ctx, "buckets", links.NewFilter())
+	if assert.NoError(err) {
+		assert.Empty(results, "Should delete page text")
+	}
+
+	link = store.NewLink(u, "wikipedia", "Kanji", "default")
+	link.Text = "a" + strings.Repeat("語", 100) + ",needle," + strings.Repeat("語", 300)
+	if err := store.SaveLink(ctx, link); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err = store.Search(ctx, "needle", links.NewFilter())
+	if assert.NoError(err) && assert.Equal(1, len(results)) {
+		snippet := results[0].Snippet
+		assert.True(utf8.ValidString(snippet), "Should cut snippet between runes, got %q", snippet)
+		assert.Contains(snippet, ",needle,")
+	}
+}
+
+func FilterByTitle(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+
+	for i, title := range []string{"Go in Action", "Learning Go", "C++ Primer"} {
+		cmd.Execute(path, []string{
+			"add",
+			fmt.Sprintf("https://example.com/%d", i),
+			"--skip-title-fetch",
+			"-t", title,
+		})
+	}
+
+	count := func(title string, modes ...links.TitleMode) int {
+		found, err := store.FindLinks(context.Background(), links.NewFilter(
+			links.WithTitle(title, modes...),
+		))
+		if err != nil {
+			t.Error(err)
+		}
+		return len(found)
+	}
+
+	assert.Equal(2, count("Go"), "Should match title at both ends")
+	assert.Equal(0, count("go"), "Should be case-sensitive by default")
+	assert.Equal(2, count("go", links.IgnoreCase), "Should ignore case")
+	assert.Equal(1, count("C++"), "Should escape user input")
+	assert.Equal(1, count("^Go", links.MatchRegex), "Should match regex")
+	assert.Equal(1, count("lrngo", links.MatchFuzzy), "Should match fuzzy")
+}
+
+func FilterByQuery(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+
+	for _, args := range [][]string{
+		{"https://www.youtube.com/1", "reading", "Rust ownership"},
+		{"https://blog.example.com/2", "reading", "Rust in production"},
+		{"https://blog.example.com/3", "watch", "Rust async talk"},
+		{"https://blog.example.com/4", "default", "Rust for beginners"},
+		{"https://blog.example.com/5", "watch", "Go generics"},
+	} {
+		cmd.Execute(path, []string{
+			"add", args[0],
+			"--skip-title-fetch",
+			"-l", args[1],
+			"-t", args[2],
+		})
+	}
+
+	count := func(text string) int {
+		query, err := links.ParseQuery(text)
+		if err != nil {
+			t.Error(err)
+			return -1
+		}
+
+		found, err := store.FindLinks(context.Background(), links.NewFilter(links.MatchQuery(query)))
+		if err != nil {
+			t.Error(err)
+		}
+		return len(found)
+	}
+
+	assert.Equal(2, count("list:(reading|watch) -source:youtube rust"),
+		"Should combine or, not and bare words")
+	assert.Equal(1, count("rust"), "Should imply default list")
+	assert.Equal(4, count("list:* title:RUST"), "Should ignore case")
+	assert.Equal(2, count(`list:* (source:youtube | "generics")`),
+		"Should group terms")
+	assert.Equal(1, count("list:watch NOT title:/^rust/"),
+		"Should support regex and NOT")
+
+	assert.Equal(3, count(`list:* title:/^rust (in|for) (production|beginners)$|ownership/`),
+		"Should keep spaces, '|' and parentheses inside regex")
+	assert.Equal(2, count(`list:watch title:(/^go (generics)?$/ | /async talk$/)`),
+		"Should lex regex alternatives")
+	assert.Equal(1, count(`list:* title:/^rust\/?\s*in/`), "Should allow escaped slash")
+
+	for _, invalid := range []string{"list:", "(rust", "archived:maybe", "color:red", "title:/a|b", "title:/(a/"} {
+		_, err := links.ParseQuery(invalid)
+		assert.Error(err, "Should reject %q", invalid)
+	}
+}
+
+func SortAndPage(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+
+	for i, title := range []string{"b", "c", "a", "d"} {
+		cmd.Execute(path, []string{
+			"add",
+			fmt.Sprintf("https://example.com/%d", i),
+			"--skip-title-fetch",
+			"-l", "default",
+			"-t", title,
+		})
+	}
+
+	titles := func(conds ...links.FilterCondition) []string {
+		found, err := store.FindLinks(context.Background(), links.NewFilter(conds...))
+		if err != nil {
+			t.Error(err)
+		}
+
+		var result []string
+		for _, link := range found {
+			result = append(result, link.Title)
+		}
+		return result
+	}
+
+	assert.Equal([]string{"a", "b", "c", "d"}, titles(links.SortBy("title")))
+	assert.Equal([]string{"d", "a", "c", "b"}, titles(links.Reverse()),
+		"Should reverse insertion order")
+	assert.Equal([]string{"c", "a"}, titles(
+		links.SortBy("created"), links.Skip(1), links.Limit(2),
+	), "Should page through links")
+
+	_, err := store.FindLinks(context.Background(), links.NewFilter(links.SortBy("color")))
+	assert.Error(err, "Should reject unknown sort field")
+}
+
+func ArchiveSavedSearch(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+
+	for i, list := range []string{"reading", "reading", "watch"} {
+		cmd.Execute(path, []string{
+			"add",
+			fmt.Sprintf("https://example.com/%d", i),
+			"--skip-title-fetch",
+			"-l", list,
+			"-t", fmt.Sprintf("Rust %d", i),
+		})
+	}
+
+	cmd.Execute(path, []string{
+		"saved", "add", "unread-rust", "--",
+		"-l", "reading", "-t", "Rust",
+	})
+
+	search, err := store.FindSearch(context.Background(), "unread-rust")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.FindLinks(context.Background(), links.NewFilter(links.FromFilter(search.Filter)))
+	if err == nil {
+		assert.Equal(2, len(found), "Saved search should find links")
+	} else {
+		t.Error(err)
+	}
+
+	cmd.Execute(path, []string{"archive", "@unread-rust", "--yes"})
+	found, err = store.FindLinks(context.Background(), links.NewFilter(links.FromFilter(search.Filter)))
+	if err == nil {
+		assert.Empty(found, "Should archive links found by saved search")
+	} else {
+		t.Error(err)
+	}
+
+	all, err := store.FindLinks(context.Background(), links.NewFilter(
+		links.FromList("*"),
+		links.IncludeArchived(),
+	))
+	if err == nil {
+		assert.Equal(3, len(all), "Should keep links")
+	} else {
+		t.Error(err)
+	}
+
+	cmd.Execute(path, []string{"saved", "add", "rust", "--", "-t", "Rust"})
+	cmd.Execute(path, []string{"snooze", "3", "2w"})
+	list := func(args ...string) string {
+		output, err := captureOutput(func() error {
+			return cmd.Execute(path, append([]string{"list", "@rust", "--sort", "id", "-f", "{{.ID}} "}, args...))
+		})
+		assert.NoError(err)
+		return output
+	}
+
+	assert.Equal("", list(), "Should use default list of saved search")
+	assert.Equal("1 2 ", list("-l", "reading", "-a"), "Should override list of saved search")
+	assert.Equal("", list("-l", "watch"), "Should hide snoozed links")
+	assert.Equal("3 ", list("-l", "watch", "--include-snoozed"),
+		"Should override snoozed status of saved search")
+}
+
+func BulkByFilter(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+
+	for i, rawurl := range []string{
+		"https://www.youtube.com/1",
+		"https://www.youtube.com/2",
+		"https://example.com/3",
+	} {
+		cmd.Execute(path, []string{
+			"add", rawurl,
+			"--skip-title-fetch",
+			"-l", "watch",
+			"-t", fmt.Sprintf("Video %d", i),
+		})
+	}
+
+	find := func(text string) []links.Link {
+		query, err := links.ParseQuery(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := store.FindLinks(context.Background(), links.NewFilter(links.MatchQuery(query)))
+		if err != nil {
+			t.Error(err)
+		}
+		return found
+	}
+
+	cmd.Execute(path, []string{"tag", "--add", "Video", "-l", "watch", "-s", "youtube", "--dry-run"})
+	assert.Empty(find("tag:video"), "Dry run should not change links")
+
+	cmd.Execute(path, []string{"tag", "--add", "Video", "-l", "watch", "-s", "youtube", "--yes"})
+	assert.Equal(2, len(find("list:watch tag:video")), "Should tag links")
+
+	cmd.Execute(path, []string{"move", "--to", "videos", "--tag", "video", "-l", "watch", "--yes"})
+	assert.Equal(2, len(find("list:videos")), "Should move links")
+
+	cmd.Execute(path, []string{"delete", "-l", "videos", "--yes"})
+	assert.Empty(find("list:videos"), "Should delete links")
+	assert.Equal(1, len(find("list:watch")), "Should keep other links")
+}
+
+func CountAndStats(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+
+	for i, list := range []string{"reading", "reading", "watch"} {
+		cmd.Execute(path, []string{
+			"add",
+			fmt.Sprintf("https://example.com/%d", i),
+			"--skip-title-fetch",
+			"-l", list,
+		})
+	}
+
+	cmd.Execute(path, []string{"archive", "1"})
+
+	count, err := store.CountLinks(context.Background(), links.NewFilter(
+		links.FromList("reading"),
+		links.NoArchived(),
+	))
+	if err == nil {
+		assert.Equal(1, count, "Should count unread links in list")
+	} else {
+		t.Error(err)
+	}
+
+	all, err := store.FindLinks(context.Background(), links.NewFilter(links.FromList("*")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := links.NewStats(all)
+	assert.Equal(3, stats.Total)
+	assert.Equal(1, stats.Archived)
+	assert.Equal(links.Counts{Total: 2, Archived: 1, Unarchived: 1},
+		stats.Lists["reading"])
+	assert.Equal(3, stats.Sources["example"].Total)
+	assert.Equal(2, stats.OldestUnread.ID, "Should find oldest unread link")
+	if assert.Equal(1, len(stats.Weeks)) {
+		assert.Equal(3, stats.Weeks[0].Added)
+		assert.Equal(1, stats.Weeks[0].Archived)
+	}
+
+	output, err := captureOutput(func() error {
+		return cmd.Execute(path, []string{"stats", "--json"})
+	})
+	if assert.NoError(err) {
+		assert.Contains(output, `"url": "https://example.com/1"`, "Should print oldest unread link")
+		assert.NotContains(output, `"Text"`, "Should leave page text out")
+	}
+}
+
+func TypedErrors(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+
+	err := cmd.Execute(path, []string{"add", url, "--skip-title-fetch"})
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"add", url, "--skip-title-fetch"})
+	assert.NoError(err, "Should skip duplicate")
+
+	err = cmd.Execute(path, []string{"add", url, "--skip-title-fetch", "--strict"})
+	assert.True(errors.Is(err, cmd.ErrDuplicate), "Should report duplicate: %v", err)
+
+	err = cmd.Execute(path, []string{"add", "not-a-url", "--skip-title-fetch"})
+	assert.True(errors.Is(err, cmd.ErrInvalidURL), "Should report invalid URL: %v", err)
+
+	err = cmd.Execute(path, []string{"add", "https://linkman.invalid/"})
+	assert.True(errors.Is(err, cmd.ErrFetchFailed), "Should report fetch failure: %v", err)
+
+	err = cmd.Execute(path, []string{"archive", "1", "42"})
+	assert.True(errors.Is(err, cmd.ErrNotFound), "Should report missing link: %v", err)
+
+	err = cmd.Execute(path, []string{"list", "@missing"})
+	assert.True(errors.Is(err, cmd.ErrNotFound), "Should report missing saved search: %v", err)
+
+	err = cmd.Execute(path, []string{"list", "-q", "title:("})
+	assert.Error(err, "Should report invalid query")
+
+	if links, err := getAllLinks(store); err == nil {
+		assert.Equal(1, len(links), "Should create one link")
+		assert.True(links[0].Archived, "Should archive existing link")
+	} else {
+		t.Error(err)
+	}
+}
+
+func CancelledCommands(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := cmd.ExecuteContext(ctx, path, []string{"add", url, "--skip-title-fetch"})
+	assert.True(errors.Is(err, context.Canceled), "Should stop adding: %v", err)
+
+	cmd.Execute(path, []string{"add", url, "--skip-title-fetch"})
+	err = cmd.ExecuteContext(ctx, path, []string{"archive", "1"})
+	assert.True(errors.Is(err, context.Canceled), "Should stop archiving: %v", err)
+
+	if links, err := getAllLinks(store); err == nil {
+		assert.Equal(1, len(links), "Should not add link when cancelled")
+		assert.False(links[0].Archived, "Should not archive link when cancelled")
+	} else {
+		t.Error(err)
+	}
+}
+
+func ConfigDefaults(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	config := getDataFile()
+	defer os.Remove(config)
+
+	err := ioutil.WriteFile(config, []byte("list = \"reading\"\n"), 0600)
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"add", "--config", config,
+		"--skip-title-fetch", "https://example.com/config"})
+	assert.NoError(err)
+
+	os.Setenv("LINKMAN_LIST", "env")
+	err = cmd.Execute(path, []string{"add", "--config", config,
+		"--skip-title-fetch", "https://example.com/env"})
+	assert.NoError(err)
+	os.Unsetenv("LINKMAN_LIST")
+
+	err = cmd.Execute(path, []string{"add", "--config", config,
+		"-l", "flag", "--skip-title-fetch", "https://example.com/flag"})
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"config", "--config", config, "set", "list", "watch"})
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"add", "--config", config,
+		"--skip-title-fetch", "https://example.com/set"})
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"add", "--skip-title-fetch", "https://example.com/builtin"})
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"config", "--config", config, "set", "nope", "x"})
+	assert.Error(err, "Should reject unknown setting")
+
+	err = cmd.Execute(path, []string{"config", "--config", config, "set", "fetch.timeout", "x"})
+	assert.Error(err, "Should reject invalid timeout")
+
+	os.Setenv("LINKMAN_FETCH_TIMEOUT", "soon")
+	err = cmd.Execute(path, []string{"list", "--config", "/nonexistent/config.toml"})
+	assert.Error(err, "Should validate environment without config file")
+	os.Unsetenv("LINKMAN_FETCH_TIMEOUT")
+
+	broken := getDataFile()
+	defer os.Remove(broken)
+	err = ioutil.WriteFile(broken, []byte("[fetch]\ntimeout = \"10\"\n"), 0600)
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"list", "--config", broken})
+	assert.Error(err, "Should reject invalid timeout in config file")
+	_, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"config", "--config", broken, "show"})
+	})
+	assert.NoError(err, "Should show invalid config")
+	err = cmd.Execute(path, []string{"config", "--config", broken, "set", "fetch.timeout", "10s"})
+	assert.NoError(err, "Should repair invalid config")
+	err = cmd.Execute(path, []string{"list", "--config", broken})
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"serve", "--config", config})
+	assert.Error(err, "Should refuse to serve without token")
+
+	err = cmd.Execute(path, []string{"config", "--config", config, "set", "serve.token", "secret"})
+	assert.NoError(err)
+	output, err := captureOutput(func() error {
+		return cmd.Execute(path, []string{"config", "--config", config, "show"})
+	})
+	assert.NoError(err)
+	assert.Contains(output, `serve.token = "********"`)
+	assert.NotContains(output, "secret", "Should mask the token")
+
+	expected := map[string]string{
+		"https://example.com/config":  "reading",
+		"https://example.com/env":     "env",
+		"https://example.com/flag":    "flag",
+		"https://example.com/set":     "watch",
+		"https://example.com/builtin": "default",
+	}
+
+	all := links.NewFilter(links.FromList("*"))
+	if links, err := store.FindLinks(context.Background(), all); err == nil {
+		assert.Equal(len(expected), len(links), "Should create all links")
+		for _, link := range links {
+			assert.Equal(expected[link.URL.String()], link.List,
+				"Should add %s into the list", link.URL)
+		}
+	} else {
+		t.Error(err)
+	}
+}
+
+func ProfilesAndDB(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "linkman")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	home := filepath.Join(dir, "data.db")
+	work := filepath.Join(dir, "profiles", "work.db")
+	synced := filepath.Join(dir, "sync", "links.db")
+
+	assert.NoError(cmd.Execute(home, []string{"add", "--skip-title-fetch",
+		"https://example.com/home"}))
+	assert.NoError(cmd.Execute(home, []string{"add", "--profile", "work",
+		"--skip-title-fetch", "https://example.com/work"}))
+	assert.NoError(cmd.Execute(home, []string{"add", "--db", synced,
+		"--skip-title-fetch", "https://example.com/sync"}))
+
+	os.Setenv("LINKMAN_PROFILE", "work")
+	assert.NoError(cmd.Execute(home, []string{"add", "--skip-title-fetch",
+		"https://example.com/env"}))
+	os.Unsetenv("LINKMAN_PROFILE")
+
+	err = cmd.Execute(home, []string{"list", "--profile", "../work"})
+	assert.Error(err, "Should reject profile outside of profiles directory")
+
+	expected := map[string][]string{
+		home:   {"https://example.com/home"},
+		work:   {"https://example.com/work", "https://example.com/env"},
+		synced: {"https://example.com/sync"},
+	}
+
+	for db, urls := range expected {
+		store, err := links.OpenStore(context.Background(), db)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if links, err := getAllLinks(store); err == nil {
+			found := []string{}
+			for _, link := range links {
+				found = append(found, link.URL.String())
+			}
+			assert.Equal(urls, found, "Should add links into %s", db)
+		} else {
+			t.Error(err)
+		}
+	}
+}
+
+func NamedFormats(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	config := getDataFile()
+	defer os.Remove(config)
+
+	cmd.Execute(path, []string{"add", "--skip-title-fetch",
+		"-t", "Bob's [notes]", "https://example.com/a b"})
+
+	output, err := captureOutput(func() error {
+		return cmd.Execute(path, []string{"list", "-f", "@markdown"})
+	})
+	assert.NoError(err)
+	assert.Equal("- [Bob's [notes]](https://example.com/a%20b)\n", output)
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"list", "-f",
+			`{{.ID | pad -3}}|{{.Title | truncate 5}}|{{host .URL}}|{{upper .List}}|{{json .Title}}|{{shellquote .Title}}\n`})
+	})
+	assert.NoError(err)
+	assert.Equal(`  1|Bob'…|example.com|DEFAULT|"Bob's [notes]"|'Bob'\''s [notes]'`+"\n", output)
+
+	cmd.Execute(path, []string{"config", "--config", config,
+		"set", "formats.mine", "{{.ID}}!\\n"})
+	cmd.Execute(path, []string{"config", "--config", config, "set", "format", "@mine"})
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"list", "--config", config})
+	})
+	assert.NoError(err)
+	assert.Equal("1!\n", output, "Should use format from configuration")
+
+	err = cmd.Execute(path, []string{"list", "-f", "@missing"})
+	assert.Error(err, "Should report unknown format")
+}
+
+func OpenLinks(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "linkman")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opened := filepath.Join(dir, "opened")
+	opener := filepath.Join(dir, "opener")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\n", opened)
+	if err := ioutil.WriteFile(opener, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, list := range []string{"reading", "reading", "watch"} {
+		cmd.Execute(path, []string{"add", "--skip-title-fetch", "-l", list,
+			fmt.Sprintf("https://example.com/%d", i)})
+	}
+
+	err = cmd.Execute(path, []string{"open", "3", "--wait", "--opener", opener})
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"open", "-l", "reading", "--sort", "id",
+		"--wait", "--archive-after", "--yes", "--opener", opener})
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"open", "1", "--wait", "--opener", "false"})
+	assert.Error(err, "Should report failed opener")
+
+	output, _ := ioutil.ReadFile(opened)
+	assert.Equal("https://example.com/2\nhttps://example.com/0\nhttps://example.com/1\n",
+		string(output), "Should open links in order")
+
+	reading := links.NewFilter(links.FromList("reading"), links.IncludeArchived())
+	if links, err := store.FindLinks(context.Background(), reading); err == nil {
+		assert.Equal(2, len(links))
+		for _, link := range links {
+			assert.True(link.Archived, "Should archive opened link")
+		}
+	} else {
+		t.Error(err)
+	}
+}
+
+func PickExternally(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "linkman")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	//fzf stub picks the second and the third link
+	script := "#!/bin/sh\nsed -n '2,3p'\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "fzf"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	for i := 0; i < 3; i++ {
+		cmd.Execute(path, []string{"add", "--skip-title-fetch",
+			fmt.Sprintf("https://example.com/%d", i)})
+	}
+
+	output, err := captureOutput(func() error {
+		return cmd.Execute(path, []string{"pick", "--external", "fzf"})
+	})
+	assert.NoError(err)
+	assert.Equal("https://example.com/1\nhttps://example.com/2\n", output)
+
+	err = cmd.Execute(path, []string{"pick", "--external", "fzf", "--action", "archive"})
+	assert.NoError(err)
+
+	err = cmd.Execute(path, []string{"pick", "--external", "fzf"})
+	assert.True(errors.Is(err, tui.ErrCancelled), "Should fail when nothing is picked: %v", err)
+	assert.False(errors.Is(err, context.Canceled), "Should not report interruption: %v", err)
+
+	err = cmd.Execute(path, []string{"pick", "--external", "nope", "-a"})
+	assert.Error(err, "Should reject unknown picker")
+
+	if links, err := getAllLinks(store); err == nil {
+		assert.Equal(3, len(links))
+		assert.False(links[0].Archived)
+		assert.True(links[1].Archived, "Should archive picked link")
+		assert.True(links[2].Archived, "Should archive picked link")
+	} else {
+		t.Error(err)
+	}
+}
+
+func captureOutput(run func() error) (string, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	err = run()
+	os.Stdout = stdout
+	writer.Close()
+
+	output, readErr := ioutil.ReadAll(reader)
+	if readErr != nil {
+		return "", readErr
+	}
+
+	return string(output), err
+}
+
 func getAllLinks(store links.Store) ([]links.Link, error) {
-	return store.FindLinks(links.NewFilter(
+	return store.FindLinks(context.Background(), links.NewFilter(
 		links.IncludeArchived(),
 	))
 }
@@ -161,3 +892,347 @@ func getDataFile() string {
 	defer tmpfile.Close()
 	return tmpfile.Name()
 }
+
+func NextAndRandom(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	for i, list := range []string{"reading", "watch", "reading", "reading"} {
+		cmd.Execute(path, []string{"add", "--skip-title-fetch", "-l", list,
+			fmt.Sprintf("https://example.com/%d", i)})
+	}
+	cmd.Execute(path, []string{"archive", "1"})
+
+	output, err := captureOutput(func() error {
+		return cmd.Execute(path, []string{"next", "-l", "reading", "-f", "{{.ID}}\\n"})
+	})
+	assert.NoError(err)
+	assert.Equal("3\n", output, "Should print the oldest unread link")
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"next", "-l", "reading", "--count", "5", "-f", "{{.ID}}\\n"})
+	})
+	assert.NoError(err)
+	assert.Equal("3\n4\n", output)
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"random", "-l", "*", "--count", "3",
+			"--weight", "age", "-f", "{{.ID}} "})
+	})
+	assert.NoError(err)
+	assert.ElementsMatch([]string{"2", "3", "4"}, strings.Fields(output),
+		"Should print every unread link once")
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"random", "-l", "watch", "-f", "{{.ID}}"})
+	})
+	assert.NoError(err)
+	assert.Equal("2", output)
+
+	err = cmd.Execute(path, []string{"random", "--weight", "size"})
+	assert.Error(err, "Should report unknown weight")
+
+	err = cmd.Execute(path, []string{"next", "--count", "0"})
+	assert.Error(err, "Should report invalid count")
+}
+
+func PriorityAndRating(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	for i, priority := range []string{"0", "2", "1"} {
+		cmd.Execute(path, []string{"add", "--skip-title-fetch", "-p", priority,
+			fmt.Sprintf("https://example.com/%d", i)})
+	}
+
+	err := cmd.Execute(path, []string{"add", "--skip-title-fetch", "-p", "4", "https://example.com/4"})
+	assert.Error(err, "Should reject invalid priority")
+
+	assert.NoError(cmd.Execute(path, []string{"edit", "1", "--priority", "3", "--title", "First"}))
+	assert.NoError(cmd.Execute(path, []string{"rate", "2", "4"}))
+	assert.Error(cmd.Execute(path, []string{"rate", "2", "6"}), "Should reject invalid rating")
+	assert.Error(cmd.Execute(path, []string{"edit", "1"}), "Should require a change")
+
+	err = cmd.Execute(path, []string{"edit", "1", "42", "--rating", "5"})
+	assert.True(errors.Is(err, cmd.ErrNotFound), "Should report missing link: %v", err)
+
+	output, err := captureOutput(func() error {
+		return cmd.Execute(path, []string{"list", "--sort", "priority", "-r",
+			"-f", "{{.ID}} {{.Priority}} {{.Rating}} {{.Title}}\\n"})
+	})
+	assert.NoError(err)
+	assert.Equal("1 3 5 First\n2 2 4 \n3 1 0 \n", output)
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"list", "--min-priority", "2", "--sort", "id", "-f", "{{.ID}} "})
+	})
+	assert.NoError(err)
+	assert.Equal("1 2 ", output)
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"list", "--rated", "-q", "rating:<5", "-f", "{{.ID}} "})
+	})
+	assert.NoError(err)
+	assert.Equal("2 ", output)
+}
+
+func NotesAndAnnotations(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "linkman")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	//editor stub appends a line to the edited file
+	editor := filepath.Join(dir, "editor")
+	script := "#!/bin/sh\necho 'Saved for *zeppelin* notes' >> \"$1\"\n"
+	if err := ioutil.WriteFile(editor, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("VISUAL", editor)
+	defer os.Unsetenv("VISUAL")
+
+	cmd.Execute(path, []string{"add", "--skip-title-fetch", "-t", "Bolt", "https://example.com/bolt"})
+	assert.NoError(cmd.Execute(path, []string{"note", "1"}))
+	assert.NoError(cmd.Execute(path, []string{"annotate", "1", "single", "file", "database"}))
+	assert.Error(cmd.Execute(path, []string{"annotate", "1"}), "Should require text")
+
+	err = cmd.Execute(path, []string{"note", "42"})
+	assert.True(errors.Is(err, cmd.ErrNotFound), "Should report missing link: %v", err)
+
+	output, err := captureOutput(func() error {
+		return cmd.Execute(path, []string{"list",
+			"-f", "{{.Note}}{{range .Annotations}}> {{.Text}}{{end}}"})
+	})
+	assert.NoError(err)
+	assert.Equal("Saved for *zeppelin* notes\n> single file database", output)
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"note", "1", "--show"})
+	})
+	assert.NoError(err)
+	assert.Equal("Saved for *zeppelin* notes\n", output)
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"annotate", "1", "--show"})
+	})
+	assert.NoError(err)
+	assert.Contains(output, "  single file database\n")
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"list", "-f", "@markdown"})
+	})
+	assert.NoError(err)
+	assert.Equal("- [Bolt](https://example.com/bolt)\n  Saved for *zeppelin* notes\n", output)
+
+	for _, query := range []string{"zeppelin", "database"} {
+		output, err = captureOutput(func() error {
+			return cmd.Execute(path, []string{"search", query, "-f", "{{.ID}}"})
+		})
+		assert.NoError(err)
+		assert.Equal("1", output, "Should find link by %s", query)
+	}
+}
+
+func SnoozeAndDue(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	for i := 0; i < 3; i++ {
+		cmd.Execute(path, []string{"add", "--skip-title-fetch",
+			"-t", fmt.Sprintf("Page %d", i), fmt.Sprintf("https://example.com/%d", i)})
+	}
+
+	list := func(args ...string) string {
+		output, err := captureOutput(func() error {
+			return cmd.Execute(path, append([]string{"list", "--sort", "id", "-f", "{{.ID}} "}, args...))
+		})
+		assert.NoError(err)
+		return output
+	}
+
+	assert.NoError(cmd.Execute(path, []string{"snooze", "1", "2", "2w"}))
+	assert.Equal("3 ", list(), "Should hide snoozed links")
+	assert.Equal("1 2 3 ", list("--include-snoozed"))
+	assert.Equal("1 2 ", list("--snoozed"))
+	assert.Equal("1 2 ", list("-q", "snoozed:true"))
+
+	search := func(args ...string) string {
+		output, err := captureOutput(func() error {
+			return cmd.Execute(path, append([]string{"search", "page", "-f", "{{.ID}} "}, args...))
+		})
+		assert.NoError(err)
+		return output
+	}
+
+	assert.Equal("3 ", search(), "Should hide snoozed links from search")
+	assert.Equal("1 2 3 ", search("--include-snoozed"))
+
+	assert.NoError(cmd.Execute(path, []string{"snooze", "2", "--clear"}))
+	assert.Equal("2 3 ", list())
+	assert.Error(cmd.Execute(path, []string{"snooze", "3", "soon"}), "Should reject invalid time")
+
+	assert.NoError(cmd.Execute(path, []string{"edit", "3", "--remind", "2000-01-02"}))
+	assert.Error(cmd.Execute(path, []string{"snooze", "2", "2000-01-01 10:00"}), "Should reject past time")
+
+	_, err := store.UpdateLinks(context.Background(), links.NewFilter(links.WithIDs(2)), func(link *links.Link) {
+		link.Snooze(time.Date(2000, 1, 1, 10, 0, 0, 0, time.Local), true)
+	})
+	assert.NoError(err)
+
+	due := func(args ...string) string {
+		output, err := captureOutput(func() error {
+			return cmd.Execute(path, append([]string{"due", "-f", "{{.ID}} "}, args...))
+		})
+		assert.NoError(err)
+		return output
+	}
+
+	assert.Equal("2 3 ", due(), "Should print due links, the earliest first")
+	assert.Equal("2 3 ", due("--clear"))
+	assert.Equal("", due(), "Should clear reminders")
+	assert.Equal("2 3 ", list(), "Should show links snoozed until past")
+}
+
+func RetentionPolicies(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	config := getDataFile()
+	defer os.Remove(config)
+
+	for i := 1; i <= 5; i++ {
+		cmd.Execute(path, []string{"add", "--skip-title-fetch", "-l", "news", fmt.Sprintf("https://example.com/%d", i)})
+	}
+	cmd.Execute(path, []string{"add", "--skip-title-fetch", "https://example.com/other"})
+	cmd.Execute(path, []string{"add", "--skip-title-fetch", "-l", "news", "https://example.com/legacy"})
+
+	ctx := context.Background()
+	_, err := store.UpdateLinks(ctx, links.NewFilter(links.WithIDs(1, 6)), func(link *links.Link) {
+		link.Created = time.Now().AddDate(0, 0, -30)
+	})
+	assert.NoError(err)
+	_, err = store.UpdateLinks(ctx, links.NewFilter(links.WithIDs(5)), func(link *links.Link) {
+		link.Archived = true
+		link.ArchivedAt = time.Now().AddDate(0, 0, -100)
+	})
+	assert.NoError(err)
+	_, err = store.UpdateLinks(ctx, links.NewFilter(links.WithIDs(7)), func(link *links.Link) {
+		link.Archived = true
+		link.ArchivedAt = time.Time{}
+		link.Created = time.Time{}
+	})
+	assert.NoError(err)
+
+	assert.Error(cmd.Execute(path, []string{"gc", "--config", config}), "Should fail without policies")
+	for key, value := range map[string]string{"archive-after": "14", "delete-after": "60", "keep": "2"} {
+		assert.NoError(cmd.Execute(path, []string{"config", "--config", config, "set", "retention.news." + key, value}))
+	}
+	assert.Error(cmd.Execute(path, []string{"config", "--config", config, "set", "retention.news.keep", "x"}))
+	assert.Error(cmd.Execute(path, []string{"config", "--config", config, "set", "retention.news", "2"}))
+	assert.Error(cmd.Execute(path, []string{"gc", "--config", config, "default"}), "Should fail for list without policy")
+
+	output, err := captureOutput(func() error {
+		return cmd.Execute(path, []string{"gc", "--config", config, "--dry-run"})
+	})
+	assert.NoError(err)
+	assert.Contains(output, "Would archive 2 links:")
+	assert.Contains(output, "Would delete 1 links:", "Should keep links archived at unknown time")
+
+	all := func() map[int]bool {
+		found, err := store.FindLinks(ctx, links.NewFilter(links.FromList("*"), links.IncludeArchived()))
+		assert.NoError(err)
+
+		archived := map[int]bool{}
+		for _, link := range found {
+			archived[link.ID] = link.Archived
+		}
+		return archived
+	}
+
+	before := map[int]bool{1: false, 2: false, 3: false, 4: false, 5: true, 6: false, 7: true}
+	assert.Equal(before, all(), "Should not change links on dry run")
+
+	output, err = captureOutput(func() error {
+		return cmd.Execute(path, []string{"gc", "--config", config, "news"})
+	})
+	assert.NoError(err)
+	assert.Contains(output, "Archived 2 links:")
+	assert.Contains(output, "Deleted 1 links:")
+	assert.Equal(map[int]bool{1: true, 2: true, 3: false, 4: false, 6: false, 7: true}, all(),
+		"Should archive the oldest and old links, delete long archived ones")
+
+	found, err := store.FindLinks(ctx, links.NewFilter(links.WithIDs(7), links.IncludeArchived()))
+	if assert.NoError(err) && assert.Equal(1, len(found)) {
+		assert.False(found[0].ArchivedAt.IsZero(), "Should stamp links archived at unknown time")
+	}
+}
+
+func RoutingRules(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	config := getDataFile()
+	defer os.Remove(config)
+
+	err := ioutil.WriteFile(config, []byte(`
+Opener = "firefox"
+
+[[rules]]
+name = "videos"
+source = "youtube"
+list = "watch"
+tags = ["video"]
+title-template = "{{host .URL}}: {{.Title | truncate 6}}"
+`), 0600)
+	assert.NoError(err)
+
+	output, err := captureOutput(func() error {
+		return cmd.Execute(path, []string{"rules", "test", "--config", config,
+			"-t", "Never gonna", "https://www.youtube.com/watch?v=1"})
+	})
+	assert.NoError(err)
+	assert.Contains(output, "Matched videos")
+	assert.Contains(output, "List: watch")
+	assert.Contains(output, "Title: www.youtube.com: Never…", "Should have format functions")
+
+	assert.NoError(cmd.Execute(path, []string{"config", "--config", config, "set", "list", "inbox"}))
+	text, err := ioutil.ReadFile(config)
+	assert.NoError(err)
+	assert.Contains(string(text), "Opener = ", "Should keep keys as they are")
+	assert.Contains(string(text), `title-template = "{{host .URL}}: {{.Title | truncate 6}}"`,
+		"Should keep rules")
+
+	assert.NoError(cmd.Execute(path, []string{"add", "--config", config,
+		"--skip-title-fetch", "https://www.youtube.com/watch?v=1"}))
+	assert.NoError(cmd.Execute(path, []string{"add", "--config", config,
+		"--skip-title-fetch", "-l", "music", "https://www.youtube.com/watch?v=2"}))
+
+	found, err := store.FindLinks(context.Background(), links.NewFilter(links.FromList("*")))
+	if assert.NoError(err) && assert.Equal(2, len(found)) {
+		assert.Equal("watch", found[0].List, "Should route link by rule")
+		assert.Equal([]string{"video"}, found[0].Tags)
+		assert.Equal("music", found[1].List, "Should not route link added into a list")
+	}
+
+	err = ioutil.WriteFile(config, []byte("[[rules]]\npath = \"(\"\n"), 0600)
+	assert.NoError(err)
+	assert.Error(cmd.Execute(path, []string{"list", "--config", config}), "Should reject invalid rule")
+}
+
+func SourceRules(path string, store links.Store, t *testing.T) {
+	assert := assert.New(t)
+	config := getDataFile()
+	defer os.Remove(config)
+
+	err := ioutil.WriteFile(config, []byte(`
+[[sources]]
+pattern = "github.com/{owner}"
+`), 0600)
+	assert.NoError(err)
+
+	for _, rawurl := range []string{"https://github.com/golang/go", "http://localhost:8080/"} {
+		assert.NoError(cmd.Execute(path, []string{"add", "--config", config, "--skip-title-fetch", rawurl}))
+	}
+
+	found, err := store.FindLinks(context.Background(), links.NewFilter())
+	if assert.NoError(err) && assert.Equal(2, len(found)) {
+		assert.Equal("github/golang", found[0].Source, "Should calculate source by rule")
+		assert.Equal("localhost", found[1].Source, "Should fall back to hostname")
+	}
+
+	err = ioutil.WriteFile(config, []byte("[[sources]]\npattern = \"\"\n"), 0600)
+	assert.NoError(err)
+	assert.Error(cmd.Execute(path, []string{"list", "--config", config}), "Should reject invalid source rule")
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dikeert/linkman/config"
+	"github.com/dikeert/linkman/linkman"
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manages configuration",
+	Long: `'config' prints and changes settings kept in
+$XDG_CONFIG_HOME/linkman/config.toml, use --config to point
+at another file.
+
+Settings:
+
+ - list: list links are added to and listed from, 'default'
+ - format: output template of 'list', 'next' and 'random' commands or @name of a format
+ - fetch.timeout: how long fetching of a page may take, '30s',
+   '0s' for no limit
+ - fetch.user-agent: User-Agent pages are fetched with
+ - db: path to links database, $XDG_DATA_HOME/linkman/data.db
+ - profile: profile which database is used when db isn't set,
+   see 'linkman profiles --help'
+ - formats.name: named output template, use it as --format @name
+ - opener: command links are opened with by 'open', 'xdg-open'
+ - openers.list: command links from the list are opened with
+ - serve.token: token clients of 'serve' authenticate with,
+   'config show' masks it
+ - retention.list.archive-after, retention.list.delete-after,
+   retention.list.keep: retention policy of the list, days or
+   number of links, see 'linkman gc --help'
+ - rules: rules links added without a list are routed by,
+   only set in the file, see 'linkman rules --help'
+ - sources: rules sources of added links are calculated by,
+   only set in the file, see 'linkman rules --help'
+
+Every setting can be given by LINKMAN_* environment variable
+named after it, e.g. LINKMAN_FETCH_TIMEOUT. Flags take precedence
+over environment variables, which take precedence over the file.
+
+Example config.toml:
+
+list = "reading"
+format = "@short"
+
+[fetch]
+timeout = "10s"
+
+[formats]
+short = "{{.ID}}\t{{.Title}}\n"
+
+Examples:
+
+linkman config show
+linkman config get list
+linkman config set fetch.timeout 10s
+linkman config set formats.short '{{.ID}}\t{{.Title}}\n'
+`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Prints settings in effect",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigShow,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get key",
+	Short: "Prints value of the setting",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set key value",
+	Short: "Changes the setting in configuration file",
+	Long: `'set' changes the setting in configuration file, other
+settings in the file are kept as they are, but the file is
+written anew, so comments in it are dropped.
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configPath = ""
+var settings *config.Config
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	fmt.Printf("# %s\n", settings.Path())
+	for _, setting := range settings.Settings() {
+		fmt.Printf("%s = %q\n", setting[0], setting[1])
+	}
+
+	return nil
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	value, err := settings.Get(args[0])
+	if err != nil {
+		return fail("Unable to get setting", err)
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	if err := settings.Set(args[0], args[1]); err != nil {
+		return fail("Unable to change setting", err)
+	}
+
+	return nil
+}
+
+//loadConfig reads configuration and makes it defaults of
+//the command, flags given explicitly still win.
+func loadConfig(cmd *cobra.Command, args []string) error {
+	path := configPath
+	if path == "" {
+		path = config.DefaultPath()
+	}
+
+	loaded, err := config.Load(path)
+	if err != nil {
+		return fail("Unable to load configuration", err)
+	}
+
+	settings = loaded
+	if isConfigCommand(cmd) {
+		//invalid settings are left for 'config' to fix
+		return nil
+	}
+
+	if err := settings.Validate(); err != nil {
+		return fail("Unable to load configuration", err)
+	}
+
+	if routing, err = routingRules(); err != nil {
+		return fail("Unable to load configuration", err)
+	}
+
+	if sources, err = sourceRules(); err != nil {
+		return fail("Unable to load configuration", err)
+	}
+
+	if dataPath, err = databasePath(); err != nil {
+		return err
+	}
+
+	setDefault(cmd, "list", "default", settings.DefaultList())
+	if settings.Format() != "" {
+		setDefault(cmd, "format", defaultTemplate, settings.Format())
+	}
+
+	return nil
+}
+
+func isConfigCommand(cmd *cobra.Command) bool {
+	return cmd == configCmd || cmd.Parent() == configCmd
+}
+
+//setDefault replaces built-in default of the flag unless
+//the flag is set explicitly or has other default.
+func setDefault(cmd *cobra.Command, name string, builtin string, value string) {
+	flag := cmd.Flags().Lookup(name)
+	if flag == nil || flag.Changed || flag.DefValue != builtin {
+		return
+	}
+
+	flag.Value.Set(value)
+}
+
+//clientOptions configures linkman client according to settings.
+func clientOptions() []linkman.Option {
+	return []linkman.Option{
+		linkman.WithDefaultList(settings.DefaultList()),
+		linkman.WithHTTPClient(&http.Client{Timeout: settings.FetchTimeout()}),
+		linkman.WithUserAgent(settings.UserAgent()),
+		linkman.WithRules(routing),
+		linkman.WithSources(sources),
+	}
+}
+
+func newClient(store links.Store) *linkman.Client {
+	return linkman.New(store, clientOptions()...)
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var countCmd = &cobra.Command{
+	Use:   "count [@saved-search]",
+	Short: "Prints number of links",
+	Long: `'count' prints number of links 'list' command would print
+with the same filtering flags, without fetching the links.
+
+Examples:
+
+linkman count - prints number of non-archived links in 'default' list
+linkman count -l reading -t rust
+linkman count @unread-rust
+`,
+	Args: savedSearchArg,
+	RunE: runCount,
+}
+
+var countFilter = &filterFlags{}
+
+func runCount(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	var saved *links.SavedSearch
+	if len(args) > 0 {
+		if saved, err = findSavedSearch(ctx, store, args[0]); err != nil {
+			return err
+		}
+	}
+
+	filter, err := countFilter.filter(cmd.Flags(), saved)
+	if err != nil {
+		return err
+	}
+
+	count, err := store.CountLinks(ctx, filter)
+	if err != nil {
+		return fail("Unable to count links", err)
+	}
+
+	fmt.Println(count)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(countCmd)
+	countFilter.register(countCmd.Flags())
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete [id|@saved-search]...",
+	Short: "Deletes links",
+	Long: `'delete' removes links from the database for good.
+` + bulkUsage + `
+Examples:
+
+linkman delete id
+linkman delete -A -l news --dry-run - shows archived links from
+'news' list that would be deleted
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBulk(cmd, args, deleteFlags, "delete", "Deleted", deleteLinks)
+	},
+}
+
+var deleteFlags = &bulkFlags{}
+
+func deleteLinks(ctx context.Context, store links.Store, filter links.LinkFilter) ([]links.Link, error) {
+	return store.DeleteLinks(ctx, filter)
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+	deleteFlags.register(deleteCmd.Flags())
+}
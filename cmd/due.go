@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var dueCmd = &cobra.Command{
+	Use:   "due [@saved-search]",
+	Short: "Prints links which reminders are due",
+	Long: `'due' prints links which reminders are due, the earliest
+first, see 'linkman snooze --help'. It is meant for cron jobs
+and status bars: nothing is printed when no reminders are due.
+
+By default links from all lists are taken into account,
+filtering flags of 'list' command narrow that down. Links are
+printed with --format, see 'linkman list --help'. Use --clear
+to clear reminders of printed links, so they are printed once.
+
+Examples:
+
+linkman due
+linkman due -l reading --clear | mail -E -s 'Links to read' me
+linkman count -l '*' -q due:true - prints number of due links
+`,
+	Args: savedSearchArg,
+	RunE: runDue,
+}
+
+var dueFilter = &filterFlags{}
+var dueFormat = "@oneline"
+var dueClear = false
+
+func runDue(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	tpl, err := parseOutputTemplate("output template", dueFormat)
+	if err != nil {
+		return err
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	conds, err := dueFilter.conditions(cmd.Flags(), true)
+	if err != nil {
+		return err
+	}
+
+	query, err := parseQuery(dueFilter.query)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("list") && !usesField(query, "list") {
+		conds = append(conds, links.FromList("*"))
+	}
+
+	if !dueFilter.archived && !dueFilter.onlyArchived && !usesField(query, "archived") {
+		conds = append(conds, links.NoArchived())
+	}
+
+	if len(args) > 0 {
+		saved, err := findSavedSearch(ctx, store, args[0])
+		if err != nil {
+			return err
+		}
+
+		conds = append(conds, links.FromFilter(saved.Filter))
+	}
+
+	conds = append(conds, links.DueReminders(), links.SortBy("remind", "id"))
+	found, err := store.FindLinks(ctx, links.NewFilter(conds...))
+	if err != nil {
+		return fail("Unable to fetch links", err)
+	}
+
+	writer := getOutputWriter()
+	for _, link := range found {
+		printLink(writer, tpl, link)
+	}
+
+	if err := writer.Flush(); err != nil || !dueClear || len(found) == 0 {
+		return err
+	}
+
+	ids := make([]int, 0, len(found))
+	for _, link := range found {
+		ids = append(ids, link.ID)
+	}
+
+	filter := links.NewFilter(links.WithIDs(ids...), links.IncludeArchived())
+	if _, err := store.UpdateLinks(ctx, filter, func(link *links.Link) {
+		link.RemindAt = time.Time{}
+	}); err != nil {
+		return fail("Unable to clear reminders", err)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(dueCmd)
+	dueFilter.register(dueCmd.Flags())
+
+	dueCmd.Flags().StringVarP(&dueFormat,
+		"format", "f", "@oneline",
+		"Output template or @name of a format, see 'linkman list --help'")
+
+	dueCmd.Flags().BoolVarP(&dueClear,
+		"clear", "", false,
+		"Clear reminders of printed links")
+}
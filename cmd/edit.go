@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit id [another id]...",
+	Short: "Changes title, priority, rating or reminder of links",
+	Long: `'edit' changes fields of links with specified IDs,
+only the fields given by flags are changed:
+
+ --title sets title of the links
+ --priority sets priority, from 0 to 3, higher is more important
+ --rating sets rating, from 1 to 5, 0 clears it
+ --remind sets reminder, either a delay like 3d or a date like
+   2006-01-02, see 'linkman snooze --help', 'off' clears it
+
+Examples:
+
+linkman edit 42 --title 'Bolt transactions'
+linkman edit 42 43 --priority 2
+linkman edit 42 --remind 2026-11-01
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runEdit,
+}
+
+var editTitle = ""
+var editPriority = 0
+var editRating = 0
+var editRemind = ""
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	flags := cmd.Flags()
+	if !flags.Changed("title") && !flags.Changed("priority") &&
+		!flags.Changed("rating") && !flags.Changed("remind") {
+		return fail("Nothing to change", fmt.Errorf("specify --title, --priority, --rating or --remind"))
+	}
+
+	if err := checkRanks(editPriority, editRating); err != nil {
+		return err
+	}
+
+	var remind time.Time
+	if flags.Changed("remind") && editRemind != "off" {
+		var err error
+		if remind, err = links.ParseTime(editRemind, time.Now()); err != nil {
+			return fail("Invalid reminder", err)
+		}
+	}
+
+	ids, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	return editLinks(ctx, store, ids, "Edited", func(link *links.Link) {
+		if flags.Changed("title") {
+			link.Title = editTitle
+		}
+
+		if flags.Changed("priority") {
+			link.Priority = editPriority
+		}
+
+		if flags.Changed("rating") {
+			link.Rating = editRating
+		}
+
+		if flags.Changed("remind") {
+			link.RemindAt = remind
+		}
+	})
+}
+
+//checkRanks validates priority and rating given by flags.
+func checkRanks(priority int, rating int) error {
+	if err := links.CheckPriority(priority); err != nil {
+		return fail("Invalid priority", err)
+	}
+
+	if err := links.CheckRating(rating); err != nil {
+		return fail("Invalid rating", err)
+	}
+
+	return nil
+}
+
+//parseIDs turns arguments into IDs of links.
+func parseIDs(args []string) ([]int, error) {
+	ids := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fail("Invalid ID", fmt.Errorf("%q is not an ID", arg))
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+//editLinks changes links with the IDs in a single transaction
+//and reports every changed link, done describes the change.
+func editLinks(ctx context.Context,
+	store links.Store,
+	ids []int,
+	done string,
+	update func(*links.Link)) error {
+
+	filter := links.NewFilter(links.WithIDs(ids...), links.IncludeArchived())
+	changed, err := store.UpdateLinks(ctx, filter, update)
+	if err != nil {
+		return fail("Unable to change links", err)
+	}
+
+	for _, link := range changed {
+		fmt.Printf("%s link %d\n", done, link.ID)
+	}
+
+	if err := reportMissing(ids, changed); err != nil {
+		return fail("Unable to change links", err)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+
+	editCmd.Flags().StringVarP(&editTitle,
+		"title", "t", "",
+		"New title of the links")
+
+	editCmd.Flags().IntVarP(&editPriority,
+		"priority", "p", 0,
+		"New priority of the links, from 0 to 3")
+
+	editCmd.Flags().IntVarP(&editRating,
+		"rating", "", 0,
+		"New rating of the links, from 1 to 5, 0 clears it")
+
+	editCmd.Flags().StringVarP(&editRemind,
+		"remind", "", "",
+		"When to remind about the links, 'off' clears reminder")
+}
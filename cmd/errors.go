@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dikeert/linkman/linkman"
+)
+
+//Errors commands return, use errors.Is to tell them apart.
+//Most of them are the same errors linkman package returns.
+var (
+	//ErrDuplicate tells that link for the URL already exists.
+	ErrDuplicate = linkman.ErrDuplicate
+	//ErrInvalidURL tells that supplied URL can't be used.
+	ErrInvalidURL = linkman.ErrInvalidURL
+	//ErrFetchFailed tells that page behind the URL can't be fetched.
+	ErrFetchFailed = linkman.ErrFetchFailed
+	//ErrNotFound tells that requested link or saved search doesn't exist.
+	ErrNotFound = linkman.ErrNotFound
+	//ErrDataHome tells that data directory in XDG_DATA_HOME
+	//can't be created.
+	ErrDataHome = errors.New("data directory can't be created")
+)
+
+//commandError describes what command was doing when err happened,
+//kind is one of Err* values or nil.
+type commandError struct {
+	kind error
+	msg  string
+	err  error
+}
+
+func (me *commandError) Error() string {
+	return fmt.Sprintf("%s: %s", me.msg, me.err)
+}
+
+func (me *commandError) Is(target error) bool {
+	return me.kind != nil && me.kind == target
+}
+
+func (me *commandError) Unwrap() error {
+	return me.err
+}
+
+//fail wraps err with the message, e.g. "Unable to fetch links".
+func fail(msg string, err error) error {
+	return &commandError{msg: msg, err: err}
+}
+
+//failWith wraps err with the message and marks it as kind of error.
+func failWith(kind error, msg string, err error) error {
+	return &commandError{kind: kind, msg: msg, err: err}
+}
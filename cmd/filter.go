@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+//filterFlags holds values of flags that select links,
+//shared by the commands that operate on filtered links.
+type filterFlags struct {
+	query  string
+	source string
+	list   string
+	title  string
+	tag    string
+
+	minPriority int
+	rated       bool
+
+	titleRegex   bool
+	ignoreCase   bool
+	fuzzyTitle   bool
+	requireTitle bool
+	archived     bool
+	onlyArchived bool
+
+	includeSnoozed bool
+	onlySnoozed    bool
+
+	sortBy  string
+	reverse bool
+	limit   int
+	offset  int
+}
+
+func (me *filterFlags) register(flags *pflag.FlagSet) {
+	flags.StringVarP(&me.query,
+		"query", "q", "",
+		"Show only links matching the query")
+
+	flags.StringVarP(&me.source,
+		"source", "s", "",
+		"Show only link from specified source")
+
+	flags.StringVarP(&me.list,
+		"list", "l", "default",
+		"Show only links from specified list")
+
+	flags.StringVarP(&me.title,
+		"title", "t", "",
+		"Show only links which title contains specified string")
+
+	flags.StringVarP(&me.tag,
+		"tag", "", "",
+		"Show only links tagged with specified tag")
+
+	flags.IntVarP(&me.minPriority,
+		"min-priority", "", 0,
+		"Show only links which priority is at least specified one")
+
+	flags.BoolVarP(&me.rated,
+		"rated", "", false,
+		"Show only links that have been rated")
+
+	flags.BoolVarP(&me.titleRegex,
+		"title-regex", "", false,
+		"Treat title filter as regular expression")
+
+	flags.BoolVarP(&me.ignoreCase,
+		"ignore-case", "i", false,
+		"Ignore case when filtering by title")
+
+	flags.BoolVarP(&me.fuzzyTitle,
+		"fuzzy", "", false,
+		"Use fuzzy matching when filtering by title")
+
+	flags.BoolVarP(&me.requireTitle,
+		"require-title", "T", false,
+		"When specified filters out links without title")
+
+	flags.StringVarP(&me.sortBy,
+		"sort", "", "",
+		"Order links by field: id, title, source, created, priority, rating, snoozed or remind")
+
+	flags.BoolVarP(&me.reverse,
+		"reverse", "r", false,
+		"Reverse the order of links")
+
+	flags.IntVarP(&me.limit,
+		"limit", "n", 0,
+		"Show at most that many links")
+
+	flags.IntVarP(&me.offset,
+		"offset", "", 0,
+		"Skip that many links")
+
+	flags.BoolVarP(&me.archived,
+		"archived", "a", false,
+		"Include archived links")
+
+	flags.BoolVarP(&me.onlyArchived,
+		"only-archived", "A", false,
+		"Show only archived links")
+
+	flags.BoolVarP(&me.includeSnoozed,
+		"include-snoozed", "", false,
+		"Include snoozed links")
+
+	flags.BoolVarP(&me.onlySnoozed,
+		"snoozed", "", false,
+		"Show only snoozed links")
+}
+
+//conditions turns flags into filtering conditions.
+//When onlyExplicit is set, defaults such as 'default' list,
+//non-archived and non-snoozed links are not implied, only
+//flags set explicitly are used.
+func (me *filterFlags) conditions(flags *pflag.FlagSet,
+	onlyExplicit bool) ([]links.FilterCondition, error) {
+
+	var conds []links.FilterCondition
+	parsed, err := parseQuery(me.query)
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := func(name string) bool {
+		return !onlyExplicit || flags.Changed(name)
+	}
+
+	if parsed != nil {
+		conds = append(conds, links.MatchQuery(parsed))
+	}
+
+	if me.source != "" {
+		conds = append(conds, links.WithSource(me.source))
+	}
+
+	if me.title != "" {
+		conds = append(conds, links.WithTitle(me.title, me.titleMode()))
+	}
+
+	if me.list != "" && explicit("list") &&
+		(!usesField(parsed, "list") || flags.Changed("list")) {
+		conds = append(conds, links.FromList(me.list))
+	}
+
+	if me.tag != "" {
+		conds = append(conds, links.WithTag(me.tag))
+	}
+
+	if me.minPriority > 0 {
+		conds = append(conds, links.MinPriority(me.minPriority))
+	}
+
+	if me.rated {
+		conds = append(conds, links.IsRated())
+	}
+
+	if me.requireTitle {
+		conds = append(conds, links.TitleNotEmpty())
+	}
+
+	if me.onlyArchived {
+		conds = append(conds, links.OnlyArchived())
+	} else if me.archived || (usesField(parsed, "archived") && !onlyExplicit) {
+		conds = append(conds, links.IncludeArchived())
+	} else if !onlyExplicit {
+		conds = append(conds, links.NoArchived())
+	}
+
+	if me.onlySnoozed {
+		conds = append(conds, links.OnlySnoozed())
+	} else if me.includeSnoozed {
+		conds = append(conds, links.IncludeSnoozed())
+	} else if !usesField(parsed, "snoozed") && !onlyExplicit {
+		conds = append(conds, links.NoSnoozed())
+	}
+
+	if me.sortBy != "" {
+		conds = append(conds, links.SortBy(me.sortBy))
+	}
+
+	if me.reverse {
+		conds = append(conds, links.Reverse())
+	}
+
+	return append(conds, links.Skip(me.offset), links.Limit(me.limit)), nil
+}
+
+//changed tells whether any of filtering flags was set explicitly.
+func (me *filterFlags) changed(flags *pflag.FlagSet) bool {
+	changed := false
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed && isFilterFlag(flag.Name) {
+			changed = true
+		}
+	})
+
+	return changed
+}
+
+func isFilterFlag(name string) bool {
+	probe := pflag.NewFlagSet("probe", pflag.ContinueOnError)
+	(&filterFlags{}).register(probe)
+	return probe.Lookup(name) != nil
+}
+
+func (me *filterFlags) titleMode() links.TitleMode {
+	mode := links.MatchSubstring
+	if me.titleRegex {
+		mode |= links.MatchRegex
+	}
+
+	if me.fuzzyTitle {
+		mode |= links.MatchFuzzy
+	}
+
+	if me.ignoreCase {
+		mode |= links.IgnoreCase
+	}
+
+	return mode
+}
+
+//filter builds links filter out of flags and,
+//optionally, saved search.
+func (me *filterFlags) filter(flags *pflag.FlagSet,
+	saved *links.SavedSearch) (links.LinkFilter, error) {
+
+	conds, err := me.conditions(flags, saved != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if saved != nil {
+		conds = append(conds, links.FromFilter(saved.Filter))
+	}
+
+	return links.NewFilter(conds...), nil
+}
+
+func parseQuery(text string) (*links.Query, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	parsed, err := links.ParseQuery(text)
+	if err != nil {
+		return nil, fail("Unable to parse query", err)
+	}
+
+	return parsed, nil
+}
+
+func usesField(query *links.Query, field string) bool {
+	return query != nil && query.Uses(field)
+}
+
+//isSavedSearchRef tells whether argument refers
+//to saved search, e.g. @unread.
+func isSavedSearchRef(arg string) bool {
+	return strings.HasPrefix(arg, "@") && len(arg) > 1
+}
+
+func findSavedSearch(ctx context.Context, store links.Store, ref string) (*links.SavedSearch, error) {
+	search, err := store.FindSearch(ctx, strings.TrimPrefix(ref, "@"))
+	if err != nil {
+		return nil, fail("Unable to find saved search", err)
+	}
+
+	return search, nil
+}
+
+//savedSearchArg validates that command got
+//at most one argument referring to saved search.
+func savedSearchArg(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("accepts at most one saved search, received %d", len(args))
+	}
+
+	if len(args) == 1 && !isSavedSearchRef(args[0]) {
+		return fmt.Errorf("expected saved search like @name, received %q", args[0])
+	}
+
+	return nil
+}
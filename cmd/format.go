@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+//builtinFormats are named output templates shipped with linkman,
+//formats from configuration take precedence over them.
+var builtinFormats = map[string]string{
+	"oneline":  `{{.ID}}\t{{.Title | truncate 60}}\t{{.URL}}\n`,
+	"dmenu":    `{{.ID}} {{or .Title .URL}}\n`,
+	"markdown": `- [{{or .Title .URL}}]({{.URL}})\n{{with .Note}}{{indent 2 .}}\n{{end}}`,
+	"org":      `- [[{{.URL}}][{{or .Title .URL}}]]\n{{with .Note}}{{indent 2 .}}\n{{end}}`,
+}
+
+//templateFuncs are functions available in output templates.
+var templateFuncs = template.FuncMap{
+	"truncate":   truncate,
+	"pad":        pad,
+	"host":       host,
+	"date":       date,
+	"upper":      func(value interface{}) string { return strings.ToUpper(toString(value)) },
+	"indent":     indent,
+	"json":       toJSON,
+	"shellquote": shellquote,
+}
+
+//namedFormat resolves @name into output template from configuration
+//or built-in one, other formats are returned as they are.
+func namedFormat(format string) (string, error) {
+	if !strings.HasPrefix(format, "@") {
+		return format, nil
+	}
+
+	name := strings.ToLower(strings.TrimPrefix(format, "@"))
+	if settings != nil {
+		if named, ok := settings.NamedFormat(name); ok {
+			return named, nil
+		}
+	}
+
+	if named, ok := builtinFormats[name]; ok {
+		return named, nil
+	}
+
+	return "", fmt.Errorf("format %s doesn't exist, known are %s",
+		format, strings.Join(formatNames(), ", "))
+}
+
+func formatNames() []string {
+	var names []string
+	for name := range builtinFormats {
+		names = append(names, "@"+name)
+	}
+
+	if settings != nil {
+		for name := range settings.Formats() {
+			if _, ok := builtinFormats[name]; !ok {
+				names = append(names, "@"+name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+//truncate cuts value down to length characters, e.g.
+//{{.Title | truncate 40}}.
+func truncate(length int, value interface{}) string {
+	text := toString(value)
+	if length <= 0 || utf8.RuneCountInString(text) <= length {
+		return text
+	}
+
+	runes := []rune(text)
+	if length == 1 {
+		return "…"
+	}
+
+	return string(runes[:length-1]) + "…"
+}
+
+//pad pads value with spaces up to width characters,
+//negative width pads on the left, e.g. {{.ID | pad -4}}.
+func pad(width int, value interface{}) string {
+	text := toString(value)
+	left := width < 0
+	if left {
+		width = -width
+	}
+
+	missing := width - utf8.RuneCountInString(text)
+	if missing <= 0 {
+		return text
+	}
+
+	if left {
+		return strings.Repeat(" ", missing) + text
+	}
+
+	return text + strings.Repeat(" ", missing)
+}
+
+//indent puts width spaces before every non-empty line of
+//value, e.g. {{indent 2 .Note}}. Trailing newlines are dropped.
+func indent(width int, value interface{}) string {
+	lines := strings.Split(strings.TrimRight(toString(value), "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = strings.Repeat(" ", width) + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+//host returns host name of URL, e.g. {{host .URL}}.
+func host(value interface{}) string {
+	switch u := value.(type) {
+	case *url.URL:
+		if u == nil {
+			return ""
+		}
+		return u.Hostname()
+	case url.URL:
+		return u.Hostname()
+	}
+
+	parsed, err := url.Parse(toString(value))
+	if err != nil {
+		return ""
+	}
+
+	return parsed.Hostname()
+}
+
+//date formats time using Go layout, e.g.
+//{{.Created | date "2006-01-02"}}.
+func date(layout string, value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+
+	return value.Format(layout)
+}
+
+//toJSON encodes value as JSON, e.g. {{json .Title}}.
+func toJSON(value interface{}) (string, error) {
+	if u, ok := value.(*url.URL); ok && u != nil {
+		value = u.String()
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+//shellquote quotes value for POSIX shell, e.g.
+//{{shellquote .URL}}.
+func shellquote(value interface{}) string {
+	return "'" + strings.Replace(toString(value), "'", `'\''`, -1) + "'"
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	case nil:
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc [list]...",
+	Short: "Applies retention policies of lists",
+	Long: `'gc' archives and deletes links as retention policies of
+their lists in settings tell, all in a single transaction, and
+prints links it changed. Policies of the lists given as arguments
+are applied only, all policies when no list is given.
+
+A policy has up to three rules, leaving a rule out turns it off:
+
+ - archive-after: archive unread links added more than N days ago
+ - delete-after: delete links archived more than N days ago
+ - keep: archive the oldest unread links beyond N of them
+
+Snoozed links are left alone, links archived by 'gc' are deleted
+by later runs at the earliest. Old versions of linkman didn't
+record when links were added and archived: such links aren't
+archived for their age and the first run takes them as archived
+at that moment. Use --dry-run to only print links which would
+be archived and deleted.
+
+Example config.toml:
+
+[retention.news]
+archive-after = 14
+delete-after = 60
+keep = 100
+
+Examples:
+
+linkman config set retention.news.keep 100
+linkman gc --dry-run
+linkman gc news
+`,
+	RunE: runGc,
+}
+
+var gcDryRun = false
+
+func runGc(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	policies, err := settings.Policies()
+	if err != nil {
+		return fail("Unable to read retention policies", err)
+	}
+
+	retentions := map[string]links.Retention{}
+	for list, policy := range policies {
+		retentions[list] = links.Retention{
+			ArchiveAfter: time.Duration(policy.ArchiveAfter) * 24 * time.Hour,
+			DeleteAfter:  time.Duration(policy.DeleteAfter) * 24 * time.Hour,
+			Keep:         policy.Keep,
+		}
+	}
+
+	if len(args) > 0 {
+		chosen := map[string]links.Retention{}
+		for _, list := range args {
+			retention, ok := retentions[strings.ToLower(list)]
+			if !ok {
+				return fail("Unable to apply retention policies",
+					fmt.Errorf("list %s has no retention policy", list))
+			}
+
+			chosen[strings.ToLower(list)] = retention
+		}
+		retentions = chosen
+	}
+
+	if len(retentions) == 0 {
+		return fail("Nothing to apply",
+			fmt.Errorf("no retention policies, see 'linkman gc --help'"))
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	expired, err := store.Expire(ctx, retentions, gcDryRun)
+	if err != nil {
+		return fail("Unable to apply retention policies", err)
+	}
+
+	if gcDryRun {
+		printAffected("Would archive", expired.Archived)
+		printAffected("Would delete", expired.Deleted)
+	} else {
+		printAffected("Archived", expired.Archived)
+		printAffected("Deleted", expired.Deleted)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+
+	gcCmd.Flags().BoolVarP(&gcDryRun,
+		"dry-run", "", false,
+		"Only print links that would be archived and deleted")
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -15,7 +16,7 @@ import (
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [@saved-search]",
 	Short: "Prints stored links",
 	Long: `'list' finds and prints links that has been added before.
 It allows to specify apply certain filtering criterias:
@@ -23,9 +24,10 @@ It allows to specify apply certain filtering criterias:
  - by title
  - by archived status
  - by list
+ - by query combining any of the above with 'and', 'or' and 'not'
 
 By default it prints all non-archived links that belong to
-'default' list.
+'default' list, except snoozed ones, see 'linkman snooze --help'.
 
 You can specify output format for links. Available fields:
 
@@ -33,6 +35,30 @@ You can specify output format for links. Available fields:
  - Source: source of the link
  - Title: title of the page referenced by the link
  - URL: URL of the link
+ - List: list the link belongs to
+ - Tags: tags of the link
+ - Priority: priority of the link, from 0 to 3
+ - Rating: rating of the link, from 1 to 5, 0 when not rated
+ - Note: note of the link, see 'linkman note --help'
+ - Annotations: annotations of the link, each has Text and Created
+ - SnoozedUntil, RemindAt: when the link is snoozed until and
+   when it is due, see 'linkman snooze --help'
+
+Format can also be @name of a named format: built-in @oneline,
+@dmenu, @markdown and @org, the last two with notes, or one defined
+in configuration, see 'linkman config --help'.
+
+Templates can use functions:
+
+ - truncate N: cuts text down to N characters, {{.Title | truncate 40}}
+ - pad N: pads text with spaces up to N characters, negative N
+   pads on the left, {{.ID | pad -4}}
+ - host: host name of URL, {{host .URL}}
+ - date LAYOUT: formats time, {{.Created | date "2006-01-02"}}
+ - upper: upper-cases text, {{upper .List}}
+ - indent N: indents lines of text by N spaces, {{indent 2 .Note}}
+ - json: encodes value as JSON, {{json .Title}}
+ - shellquote: quotes text for shell, {{shellquote .URL}}
 
 Default output format:
 
@@ -48,11 +74,55 @@ linkman list -l mylist - prints links from 'mylist'
 linkman list -l '*' - prints links from all lists
 linkman list -T - prints only links which has non-empty title
 linkman list -t title - prints links that have 'title' in the title
+linkman list -i -t title - same as above, but ignores case
+linkman list --title-regex -t '^Go' - prints links which title starts with 'Go'
+linkman list --fuzzy -t bltx - prints links which title contains
+'b', 'l', 't' and 'x' in that order, e.g. 'Bolt transactions'
+
+linkman list --sort created -r -n 10 - prints 10 most recent links
+linkman list --min-priority 2 --sort priority -r - prints important
+links, the most important first
+linkman list --rated --sort rating -r - prints best rated links first
+linkman list --sort title -n 10 --offset 10 - prints second page
+of links ordered by title
+
+linkman list @unread-rust - prints links found by saved search
+'unread-rust', see 'linkman saved' for details
 
 linkman list -f '{{.ID}}:\t{{.Source}}' - prints links as
 list of "id: source" lines
+linkman list -f @markdown - prints links as markdown list
+
+Query:
+
+Query is a list of terms. Terms separated by spaces are combined
+using 'and', terms separated by '|' are combined using 'or',
+a term prefixed with '-' is negated, parentheses group terms.
+Term is field:value or a word that title should contain.
+Supported fields:
+
+ - list:name, list:* for any list
+ - source:name
+ - title:text or title:/regex/, both ignore case
+ - archived:true or archived:false
+ - snoozed:true or snoozed:false
+ - due:true or due:false
+ - id:N
+ - tag:name
+ - priority:N, priority:>=N for at least N, >, < and <= work too
+ - rating:N, comparisons work as for priority
+ - has:title, has:rating
+
+Other filtering flags are combined with the query using 'and'.
+When query filters by list, archived or snoozed status, 'default'
+list, non-archived and non-snoozed links are no longer implied.
+
+linkman list -q 'list:(reading|watch) -source:youtube rust' - prints
+links from either 'reading' or 'watch' list that are not from youtube
+and have 'rust' in the title
 `,
-	Run: runList,
+	Args: savedSearchArg,
+	RunE: runList,
 }
 
 const defaultTemplate = `
@@ -63,101 +133,111 @@ URL:	{{.URL}}
 `
 
 var format = defaultTemplate
-var source = ""
-var list = ""
-var title = ""
+var listFilter = &filterFlags{}
+
+func runList(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	template, err := getOutputTemplate()
+	if err != nil {
+		return err
+	}
 
-var requireTitle = false
-var archived = false
-var onlyArchived = false
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
 
-func runList(cmd *cobra.Command, args []string) {
-	writer := getOutputWriter()
-	template := getOutputTemplate()
-	store := openLinksStore(dataPath)
+	found, err := getLinks(cmd, store, listFilter, args)
+	if err != nil {
+		return err
+	}
 
-	for _, link := range getLinks(store) {
+	writer := getOutputWriter()
+	for _, link := range found {
 		printLink(writer, template, link)
 	}
-	writer.Flush()
+
+	return writer.Flush()
 }
 
-func openLinksStore(path string) links.Store {
-	store, err := links.OpenStore(path)
-	if err == nil {
-		return store
+func openLinksStore(ctx context.Context, path string) (links.Store, error) {
+	store, err := links.OpenStore(ctx, path)
+	if err != nil {
+		return nil, fail("Unable to open links store", err)
 	}
 
-	die("Unable to open links store", err)
-	panic("Shouldn't get there")
+	return store, nil
 }
 
 func getOutputWriter() *tabwriter.Writer {
 	return tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 }
 
-func getLinks(store links.Store) []links.Link {
-	var conds []links.FilterCondition
-
-	if source != "" {
-		conds = append(conds, links.WithSource(source))
-	}
-
-	if title != "" {
-		conds = append(conds, links.WithTitle(title))
+//getLinks finds links allowed by the filter flags and
+//saved search given as the first argument, if any.
+func getLinks(cmd *cobra.Command,
+	store links.Store,
+	flags *filterFlags,
+	args []string) ([]links.Link, error) {
+
+	ctx := commandContext(cmd)
+	var saved *links.SavedSearch
+	if len(args) > 0 {
+		var err error
+		if saved, err = findSavedSearch(ctx, store, args[0]); err != nil {
+			return nil, err
+		}
 	}
 
-	if list != "" {
-		conds = append(conds, links.FromList(list))
+	filter, err := flags.filter(cmd.Flags(), saved)
+	if err != nil {
+		return nil, err
 	}
 
-	if requireTitle {
-		conds = append(conds, links.TitleNotEmpty())
+	found, err := store.FindLinks(ctx, filter)
+	if err != nil {
+		return nil, fail("Unable to fetch links", err)
 	}
 
-	if onlyArchived {
-		conds = append(conds, links.OnlyArchived())
-	} else if archived {
-		conds = append(conds, links.IncludeArchived())
-	} else {
-		conds = append(conds, links.NoArchived())
-	}
+	return found, nil
+}
 
-	filter := links.NewFilter(conds...)
+func getOutputTemplate() (*template.Template, error) {
+	return parseOutputTemplate("output template", format)
+}
 
-	links, err := store.FindLinks(filter)
-	if err == nil {
-		return links
+//parseOutputTemplate parses output template given by a flag,
+//format is either a template or @name of a named one,
+//see unescapeOutputTemplate.
+func parseOutputTemplate(name string, format string) (*template.Template, error) {
+	format, err := namedFormat(format)
+	if err != nil {
+		return nil, fail("Unable to parse output template", err)
 	}
 
-	die("Unable to fetch links", err)
-	panic("Shouldn't get there")
-}
-
-func getOutputTemplate() *template.Template {
-	tpl := template.New("output template")
-	format := unescapeOutputTemplate(format)
+	format, err = unescapeOutputTemplate(format)
+	if err != nil {
+		return nil, err
+	}
 
-	tpl, err := tpl.Parse(format)
-	if err == nil {
-		return tpl
+	tpl, err := template.New(name).Funcs(templateFuncs).Parse(format)
+	if err != nil {
+		return nil, fail("Unable to parse output template", err)
 	}
 
-	die("Unable to parse output template", err)
-	panic("Shouldn't get there")
+	return tpl, nil
 }
 
-func unescapeOutputTemplate(format string) string {
+func unescapeOutputTemplate(format string) (string, error) {
 	quoted := strconv.Quote(format)
 	replaced := strings.Replace(quoted, `\\`, "\\", -1)
 
 	result, err := strconv.Unquote(replaced)
-	if err == nil {
-		return result
+	if err != nil {
+		return "", fail("Unable to parse output template", err)
 	}
 
-	die("Unable to parse output template", err)
-	panic("Shouldn't get there")
+	return result, nil
 }
 
 func printLink(writer *tabwriter.Writer,
@@ -175,29 +255,7 @@ func init() {
 	listCmd.Flags().StringVarP(&format,
 		"format", "f",
 		defaultTemplate,
-		"Output template. Available fields are: ID, URL, Source, Title,List")
-
-	listCmd.Flags().StringVarP(&source,
-		"source", "s", "",
-		"Show only link from specified source")
-
-	listCmd.Flags().StringVarP(&list,
-		"list", "l", "default",
-		"Show only links from specified list")
-
-	listCmd.Flags().StringVarP(&title,
-		"title", "t", "",
-		"Show only links which title contains specified string")
-
-	listCmd.Flags().BoolVarP(&requireTitle,
-		"require-title", "T", false,
-		"When specified filters out links without title")
-
-	listCmd.Flags().BoolVarP(&archived,
-		"archived", "a", false,
-		"Include archived links")
+		"Output template or @name of a format. Available fields are: ID, URL, Source, Title, List, Tags, Priority, Rating, Note")
 
-	listCmd.Flags().BoolVarP(&onlyArchived,
-		"only-archived", "A", false,
-		"Show only archived links")
+	listFilter.register(listCmd.Flags())
 }
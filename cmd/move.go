@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var moveCmd = &cobra.Command{
+	Use:   "move --to list [id|@saved-search]...",
+	Short: "Moves links to another list",
+	Long: `'move' puts links into the list specified by --to.
+` + bulkUsage + `
+Examples:
+
+linkman move --to reading id
+linkman move --to watch -l reading -s youtube --yes
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if moveTarget == "" {
+			return fail("Unable to move links", fmt.Errorf("target list is not specified"))
+		}
+
+		return runBulk(cmd, args, moveFlags, "move", "Moved", moveLinks)
+	},
+}
+
+var moveFlags = &bulkFlags{}
+var moveTarget = ""
+
+func moveLinks(ctx context.Context, store links.Store, filter links.LinkFilter) ([]links.Link, error) {
+	return store.UpdateLinks(ctx, filter, func(link *links.Link) {
+		link.List = moveTarget
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(moveCmd)
+	moveFlags.register(moveCmd.Flags())
+	moveCmd.Flags().StringVarP(&moveTarget, "to", "", "", "Target list")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var nextCmd = &cobra.Command{
+	Use:   "next [@saved-search]",
+	Short: "Prints links to read next",
+	Long: `'next' prints the oldest unread link, links are read in the
+order they were added. It accepts the same filtering flags as
+'list' and prints links with the same --format, see
+'linkman list --help'.
+
+Use --count to print more than one link.
+
+Examples:
+
+linkman next - prints the oldest link of 'default' list
+linkman next -l reading --count 3
+linkman next -l reading -f '{{.URL}}\n' | xargs xdg-open
+`,
+	Args: savedSearchArg,
+	RunE: runNext,
+}
+
+var nextFilter = &filterFlags{}
+var nextCount = 1
+var nextFormat = defaultTemplate
+
+func runNext(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	if err := checkCount(nextCount); err != nil {
+		return err
+	}
+
+	tpl, err := parseOutputTemplate("output template", nextFormat)
+	if err != nil {
+		return err
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	found, err := getLinks(cmd, store, nextFilter, args)
+	if err != nil {
+		return err
+	}
+
+	writer := getOutputWriter()
+	for _, link := range links.Oldest(found, nextCount) {
+		printLink(writer, tpl, link)
+	}
+
+	return writer.Flush()
+}
+
+func checkCount(count int) error {
+	if count < 1 {
+		return fail("Unable to choose links", fmt.Errorf("count must be positive, got %d", count))
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(nextCmd)
+	nextFilter.register(nextCmd.Flags())
+
+	nextCmd.Flags().IntVarP(&nextCount,
+		"count", "c", 1,
+		"Number of links to print")
+
+	nextCmd.Flags().StringVarP(&nextFormat,
+		"format", "f", defaultTemplate,
+		"Output template or @name of a format, see 'linkman list --help'")
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note id",
+	Short: "Edits note of a link",
+	Long: `'note' opens the note of the link in $VISUAL or $EDITOR,
+'vi' when neither is set, and saves it once the editor exits.
+Notes are free text in markdown, e.g. why the link was saved
+or what was learned from it. Clear the text to remove the note.
+
+Notes are searched by 'search' and shown by templates
+as {{.Note}}, see 'linkman list --help'.
+
+Examples:
+
+linkman note 42
+linkman note 42 --show
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNote,
+}
+
+var noteShow = false
+
+func runNote(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	ids, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	link, err := findLink(ctx, store, ids[0])
+	if err != nil {
+		return err
+	}
+
+	if noteShow {
+		if link.Note != "" {
+			fmt.Println(strings.TrimRight(link.Note, "\n"))
+		}
+		return nil
+	}
+
+	note, err := editText(ctx, fmt.Sprintf("linkman-note-%d-*.md", link.ID), link.Note)
+	if err != nil {
+		return fail("Unable to edit note", err)
+	}
+
+	if note == link.Note {
+		fmt.Println("Note unchanged")
+		return nil
+	}
+
+	return editLinks(ctx, store, ids, "Changed note of", func(link *links.Link) {
+		link.Note = note
+	})
+}
+
+//findLink finds the link with the ID, archived or not.
+func findLink(ctx context.Context, store links.Store, id int) (*links.Link, error) {
+	filter := links.NewFilter(links.WithIDs(id), links.IncludeArchived())
+	found, err := store.FindLinks(ctx, filter)
+	if err != nil {
+		return nil, fail("Unable to fetch links", err)
+	}
+
+	if err := reportMissing([]int{id}, found); err != nil {
+		return nil, fail("Unable to find link", err)
+	}
+
+	return &found[0], nil
+}
+
+//editText lets user edit text in the editor, pattern names
+//the temporary file the text is edited in.
+func editText(ctx context.Context, pattern string, text string) (string, error) {
+	file, err := ioutil.TempFile("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		editor = "vi"
+	}
+
+	command := exec.CommandContext(ctx, "sh", "-c", editor+` "$@"`, "linkman", file.Name())
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		return "", fmt.Errorf("%s failed: %s", editor, err)
+	}
+
+	edited, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(string(edited)) == "" {
+		return "", nil
+	}
+
+	return string(edited), nil
+}
+
+func init() {
+	rootCmd.AddCommand(noteCmd)
+
+	noteCmd.Flags().BoolVarP(&noteShow,
+		"show", "s", false,
+		"Print the note instead of editing it")
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open [id|@saved-search]...",
+	Short: "Opens links",
+	Long: `'open' opens links with the opener command, 'xdg-open' by
+default. Opener is taken from --opener flag, 'openers.<list>'
+setting for links from that list or 'opener' setting, see
+'linkman config --help'. Opener is run by 'sh' with URL appended
+as the last argument.
+
+Links are selected by IDs, saved searches (@name) or, when
+neither is given, by the same filtering flags 'list' command
+has. Opening more than one link selected by flags or saved
+search requires confirmation, use --yes to skip it and --dry-run
+to only print links that would be opened.
+
+Use --wait to wait for the opener to exit before opening next
+link and --archive-after to archive links once they are opened,
+or once opener exits successfully when used with --wait.
+
+Examples:
+
+linkman open 42
+linkman open -l reading --sort created -n 1 --archive-after
+linkman open 42 --wait --archive-after --opener 'firefox -P ReadingMode'
+linkman config set openers.reading 'firefox -P ReadingMode --name FirefoxReadingMode'
+`,
+	RunE: runOpen,
+}
+
+var openFlags = &bulkFlags{}
+var openOpener = ""
+var openWait = false
+var openArchiveAfter = false
+
+func runOpen(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	targets, err := openFlags.targets(cmd, store, args)
+	if err != nil {
+		return err
+	}
+
+	var missing error
+	for _, target := range targets {
+		found, err := store.FindLinks(ctx, target.filter)
+		if err != nil {
+			return fail("Unable to fetch links", err)
+		}
+
+		if err := reportMissing(target.ids, found); err != nil {
+			missing = fail("Unable to open links", err)
+		}
+
+		if openFlags.dryRun {
+			printAffected("Would open", found)
+			continue
+		}
+
+		if target.confirm && !openFlags.yes && len(found) > 1 && !confirm(ctx, "open", found) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, link := range found {
+			if err := openLink(ctx, store, link, openOpener, openWait, openArchiveAfter); err != nil {
+				return err
+			}
+		}
+	}
+
+	return missing
+}
+
+//openLink runs opener for the link, empty opener stands for the one
+//from settings, waits for it to exit and archives the link when asked to.
+func openLink(ctx context.Context,
+	store links.Store,
+	link links.Link,
+	opener string,
+	wait bool,
+	archive bool) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if opener == "" {
+		opener = settings.Opener(link.List)
+	}
+
+	args := []string{"-c", opener + ` "$@"`, "linkman", link.URL.String()}
+
+	var err error
+	if wait {
+		command := exec.CommandContext(ctx, "sh", args...)
+		command.Stdout = os.Stdout
+		command.Stderr = os.Stderr
+		err = command.Run()
+	} else {
+		//opener outlives linkman, so it's not bound to ctx
+		command := exec.Command("sh", args...)
+		command.Stdout = os.Stdout
+		command.Stderr = os.Stderr
+		if err = command.Start(); err == nil {
+			err = command.Process.Release()
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil {
+		return fail(fmt.Sprintf("Unable to open link %d with %q", link.ID, opener), err)
+	}
+
+	if !archive || link.Archived {
+		return nil
+	}
+
+	filter := links.NewFilter(links.WithIDs(link.ID), links.IncludeArchived())
+	if _, err := archiveLinks(ctx, store, filter); err != nil {
+		return fail(fmt.Sprintf("Unable to archive link %d", link.ID), err)
+	}
+
+	fmt.Printf("Archived link %d\n", link.ID)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+	openFlags.register(openCmd.Flags())
+
+	openCmd.Flags().StringVarP(&openOpener,
+		"opener", "o", "",
+		"Command to open links with")
+
+	openCmd.Flags().BoolVarP(&openWait,
+		"wait", "w", false,
+		"Wait for the opener to exit")
+
+	openCmd.Flags().BoolVarP(&openArchiveAfter,
+		"archive-after", "", false,
+		"Archive links once they are opened")
+}
@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/dikeert/linkman/links"
+	"github.com/dikeert/linkman/tui"
+
+	"github.com/spf13/cobra"
+)
+
+var pickCmd = &cobra.Command{
+	Use:   "pick [@saved-search]",
+	Short: "Picks links interactively",
+	Long: `'pick' shows links allowed by filtering flags of 'list'
+command in a fuzzy finder and applies an action to chosen ones.
+
+Type to narrow links down, move with arrows (or ^P and ^N),
+select several links with Tab and choose with Enter, which
+applies --action: print (default) prints chosen links using
+--format, open opens them as 'open' does, archive archives them.
+Other actions are bound to keys:
+
+ ^Y - print links
+ ^O - open links
+ ^A - archive links
+ ^T - move links into another list, asks for its name
+
+Use --external to pick with dmenu, fzf or rofi instead, 'pick'
+feeds them links and finds out which were chosen. When nothing
+is chosen, e.g. Esc is pressed, 'pick' exits with code 1.
+
+Examples:
+
+linkman pick -l reading
+linkman pick -l reading --action open
+linkman pick --external dmenu --action open
+linkman pick -f '{{.ID}}\n' | xargs linkman tag --add later
+`,
+	Args: savedSearchArg,
+	RunE: runPick,
+}
+
+const defaultPickTemplate = `{{.URL}}\n`
+
+var pickFilter = &filterFlags{}
+var pickAction = "print"
+var pickExternal = ""
+var pickFormat = defaultPickTemplate
+
+var pickBindings = []tui.Binding{
+	{Key: tui.Ctrl('y'), Action: "print"},
+	{Key: tui.Ctrl('o'), Action: "open"},
+	{Key: tui.Ctrl('a'), Action: "archive"},
+	{Key: tui.Ctrl('t'), Action: "move", Ask: "Move to list: "},
+}
+
+//externalPickers are commands --external runs, links are
+//given to them as lines starting with ID.
+var externalPickers = map[string][]string{
+	"dmenu": {"dmenu", "-i", "-l", "20", "-p", "linkman"},
+	"rofi":  {"rofi", "-dmenu", "-i", "-multi-select", "-p", "linkman"},
+	"fzf":   {"fzf", "--multi", "--delimiter", "\t", "--with-nth", "2.."},
+}
+
+func runPick(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	if err := checkPickAction(pickAction); err != nil {
+		return err
+	}
+
+	tpl, err := parseOutputTemplate("pick template", pickFormat)
+	if err != nil {
+		return err
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	found, err := getLinks(cmd, store, pickFilter, args)
+	if err != nil {
+		return err
+	}
+
+	if len(found) == 0 {
+		return fail("Unable to pick links", fmt.Errorf("no links found"))
+	}
+
+	var choice *tui.Choice
+	if pickExternal != "" {
+		choice, err = pickExternally(ctx, pickExternal, found)
+	} else {
+		choice, err = pickInteractively(ctx, found)
+	}
+
+	if errors.Is(err, tui.ErrCancelled) {
+		//leaving the picker is not an interruption, exit code 130
+		//is kept for signals
+		return fail("Nothing picked", err)
+	} else if err != nil {
+		return fail("Unable to pick links", err)
+	}
+
+	action := choice.Action
+	if action == "" {
+		action = pickAction
+	}
+
+	chosen := make([]links.Link, 0, len(choice.Items))
+	for _, index := range choice.Items {
+		chosen = append(chosen, found[index])
+	}
+
+	switch action {
+	case "print":
+		writer := getOutputWriter()
+		for _, link := range chosen {
+			printLink(writer, tpl, link)
+		}
+		return writer.Flush()
+	case "open":
+		for _, link := range chosen {
+			if err := openLink(ctx, store, link, "", false, false); err != nil {
+				return err
+			}
+		}
+	case "archive":
+		return changePicked(ctx, store, chosen, "Archived", archiveLinks)
+	case "move":
+		return changePicked(ctx, store, chosen, "Moved", func(ctx context.Context,
+			store links.Store,
+			filter links.LinkFilter) ([]links.Link, error) {
+
+			return store.UpdateLinks(ctx, filter, func(link *links.Link) {
+				link.List = choice.Input
+			})
+		})
+	}
+
+	return nil
+}
+
+func checkPickAction(action string) error {
+	switch action {
+	case "print", "open", "archive":
+		return nil
+	}
+
+	return fail("Unable to pick links",
+		fmt.Errorf("unknown action %q, use print, open or archive", action))
+}
+
+//pickInteractively shows built-in fuzzy finder on the terminal.
+func pickInteractively(ctx context.Context, found []links.Link) (*tui.Choice, error) {
+	tty, err := tui.OpenTTY(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tty.Close()
+
+	picker := &tui.Picker{
+		Prompt:   "> ",
+		Items:    pickItems(found),
+		Multi:    true,
+		Bindings: pickBindings,
+	}
+
+	choice, err := picker.Run(tty)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return choice, err
+}
+
+//pickItems describes links for the picker: title, source and list.
+func pickItems(found []links.Link) []string {
+	items := make([]string, 0, len(found))
+	for _, link := range found {
+		title := link.Title
+		if title == "" {
+			title = link.URL.String()
+		}
+
+		items = append(items, fmt.Sprintf("%s  [%s] (%s)", title, link.Source, link.List))
+	}
+
+	return items
+}
+
+//pickExternally runs dmenu, fzf or rofi, feeds it links
+//prefixed with IDs and finds chosen links by the IDs.
+func pickExternally(ctx context.Context, name string, found []links.Link) (*tui.Choice, error) {
+	command, ok := externalPickers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown picker %q, use dmenu, fzf or rofi", name)
+	}
+
+	separator := "  "
+	if name == "fzf" {
+		//fzf hides IDs, they are in the first tab separated field
+		separator = "\t"
+	}
+
+	indexes := map[int]int{}
+	var input bytes.Buffer
+	for i, item := range pickItems(found) {
+		indexes[found[i].ID] = i
+		fmt.Fprintf(&input, "%d%s%s\n", found[i].ID, separator, item)
+	}
+
+	var output bytes.Buffer
+	picker := exec.CommandContext(ctx, command[0], command[1:]...)
+	picker.Stdin = &input
+	picker.Stdout = &output
+	picker.Stderr = os.Stderr
+
+	if err := picker.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && (exit.ExitCode() == 1 || exit.ExitCode() == 130) {
+			return nil, tui.ErrCancelled
+		}
+
+		return nil, fmt.Errorf("%s failed: %s", name, err)
+	}
+
+	choice := &tui.Choice{}
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		field := strings.Fields(scanner.Text())
+		if len(field) == 0 {
+			continue
+		}
+
+		id, err := strconv.Atoi(field[0])
+		if index, ok := indexes[id]; ok && err == nil {
+			choice.Items = append(choice.Items, index)
+		}
+	}
+
+	if len(choice.Items) == 0 {
+		return nil, tui.ErrCancelled
+	}
+
+	return choice, nil
+}
+
+//changePicked applies action to chosen links.
+func changePicked(ctx context.Context,
+	store links.Store,
+	chosen []links.Link,
+	done string,
+	action bulkAction) error {
+
+	ids := make([]int, 0, len(chosen))
+	for _, link := range chosen {
+		ids = append(ids, link.ID)
+	}
+
+	filter := links.NewFilter(links.WithIDs(ids...), links.IncludeArchived())
+	changed, err := action(ctx, store, filter)
+	if err != nil {
+		return fail("Unable to change links", err)
+	}
+
+	fmt.Printf("%s %d links\n", done, len(changed))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(pickCmd)
+	pickFilter.register(pickCmd.Flags())
+
+	pickCmd.Flags().StringVarP(&pickAction,
+		"action", "", "print",
+		"Action Enter applies: print, open or archive")
+
+	pickCmd.Flags().StringVarP(&pickExternal,
+		"external", "", "",
+		"Pick with external program: dmenu, fzf or rofi")
+
+	pickCmd.Flags().StringVarP(&pickFormat,
+		"format", "f", defaultPickTemplate,
+		"Output template or @name of a format printed links use")
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dikeert/linkman/config"
+	"github.com/dikeert/linkman/data"
+
+	"github.com/spf13/cobra"
+)
+
+var profilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "Prints profiles",
+	Long: `'profiles' prints profiles and their databases, the one
+in use is marked with '*', '(db)' stands for database given
+by --db.
+
+Profile is a separate set of links, e.g. work and personal ones.
+Default profile lives in $XDG_DATA_HOME/linkman/data.db, others
+in $XDG_DATA_HOME/linkman/profiles/<name>.db. Profile is created
+as soon as it's used for the first time.
+
+Choose profile with --profile flag, LINKMAN_PROFILE environment
+variable or 'profile' setting. Database given by --db, LINKMAN_DB
+or 'db' setting takes precedence over profile.
+
+Examples:
+
+linkman --profile work add https://example.com
+linkman --profile work list
+linkman config set profile work - uses 'work' profile by default
+linkman --db ~/Sync/links.db list - uses database in synced folder
+`,
+	Args: cobra.NoArgs,
+	RunE: runProfiles,
+}
+
+//Default database is dataFile in dataDir of XDG_DATA_HOME.
+const dataDir = "linkman"
+const dataFile = "data.db"
+
+func runProfiles(cmd *cobra.Command, args []string) error {
+	defaultPath, err := defaultDatabasePath()
+	if err != nil {
+		return err
+	}
+
+	profiles, err := data.Profiles(defaultPath)
+	if err != nil {
+		return fail("Unable to find profiles", err)
+	}
+
+	writer := getOutputWriter()
+	current := false
+	for _, profile := range profiles {
+		path, err := data.GetProfilePath(defaultPath, profile)
+		if err != nil {
+			return fail("Unable to find profiles", err)
+		}
+
+		mark := " "
+		if path == dataPath {
+			mark = "*"
+			current = true
+		}
+
+		fmt.Fprintf(writer, "%s %s\t%s\n", mark, profile, path)
+	}
+
+	if !current {
+		fmt.Fprintf(writer, "* (db)\t%s\n", dataPath)
+	}
+
+	return writer.Flush()
+}
+
+//databasePath chooses database by --db and --profile flags
+//falling back to the same settings and then to the default one.
+func databasePath() (string, error) {
+	db, profile := dbFlag, profileFlag
+	if db == "" && profile == "" {
+		db, profile = settings.DB(), settings.Profile()
+	}
+
+	if db != "" {
+		db = config.ExpandHome(db)
+		if err := os.MkdirAll(filepath.Dir(db), 0700); err != nil {
+			return "", fail("Unable to create database directory", err)
+		}
+
+		return db, nil
+	}
+
+	defaultPath, err := defaultDatabasePath()
+	if err != nil {
+		return "", err
+	}
+
+	if profile == "" {
+		return defaultPath, nil
+	}
+
+	path, err := data.GetProfilePath(defaultPath, profile)
+	if err != nil {
+		return "", fail("Unable to use profile", err)
+	}
+
+	return path, nil
+}
+
+//defaultDatabasePath returns path given to Execute, when it's
+//empty the database in XDG_DATA_HOME, which directory is
+//created only then.
+func defaultDatabasePath() (string, error) {
+	if defaultPath != "" {
+		return defaultPath, nil
+	}
+
+	path, err := data.GetFilePath(dataDir, dataFile)
+	if err != nil {
+		return "", failWith(ErrDataHome, "Unable to create data directory", err)
+	}
+
+	return path, nil
+}
+
+func init() {
+	rootCmd.AddCommand(profilesCmd)
+}
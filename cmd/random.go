@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var randomCmd = &cobra.Command{
+	Use:   "random [@saved-search]",
+	Short: "Prints random links",
+	Long: `'random' prints a link chosen at random among links allowed
+by the same filtering flags 'list' has. Links are printed with
+the same --format, see 'linkman list --help'.
+
+Every link has the same chance by default, use --weight age to
+prefer links added long ago: a link added N days ago is N+1
+times as likely to be chosen as one added today. Similarly
+--weight priority prefers links with higher priority, a link
+with priority N weighs N+1.
+
+Use --count to print more than one link, the same link is
+never printed twice.
+
+Examples:
+
+linkman random -l reading
+linkman random -l reading --weight age --count 3
+linkman random -l reading --weight priority --min-priority 1
+linkman random -l watch -f '{{.URL}}\n' | xargs mpv
+`,
+	Args: savedSearchArg,
+	RunE: runRandom,
+}
+
+var randomFilter = &filterFlags{}
+var randomCount = 1
+var randomWeight = "uniform"
+var randomFormat = defaultTemplate
+
+//weights are the ways --weight can choose links.
+var weights = map[string]links.Weight{
+	"uniform":  links.Uniform,
+	"age":      links.ByAge,
+	"priority": links.ByPriority,
+}
+
+func runRandom(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	if err := checkCount(randomCount); err != nil {
+		return err
+	}
+
+	weight, ok := weights[randomWeight]
+	if !ok {
+		return fail("Unable to choose links",
+			fmt.Errorf("unknown weight %q, use uniform, age or priority", randomWeight))
+	}
+
+	tpl, err := parseOutputTemplate("output template", randomFormat)
+	if err != nil {
+		return err
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	found, err := getLinks(cmd, store, randomFilter, args)
+	if err != nil {
+		return err
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	writer := getOutputWriter()
+	for _, link := range links.Random(found, randomCount, weight, rnd) {
+		printLink(writer, tpl, link)
+	}
+
+	return writer.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(randomCmd)
+	randomFilter.register(randomCmd.Flags())
+
+	randomCmd.Flags().IntVarP(&randomCount,
+		"count", "c", 1,
+		"Number of links to print")
+
+	randomCmd.Flags().StringVarP(&randomWeight,
+		"weight", "w", "uniform",
+		"How links are weighted: uniform, age or priority")
+
+	randomCmd.Flags().StringVarP(&randomFormat,
+		"format", "f", defaultTemplate,
+		"Output template or @name of a format, see 'linkman list --help'")
+}
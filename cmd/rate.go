@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var rateCmd = &cobra.Command{
+	Use:   "rate id rating",
+	Short: "Rates a link",
+	Long: `'rate' sets rating of the link, from 1 to 5, usually once
+the link is read. Rating 0 clears it.
+
+Use 'linkman list --rated --sort rating -r' to show best
+rated links first.
+
+Examples:
+
+linkman rate 42 5
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRate,
+}
+
+func runRate(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	ids, err := parseIDs(args[:1])
+	if err != nil {
+		return err
+	}
+
+	rating, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fail("Invalid rating", fmt.Errorf("%q is not a number", args[1]))
+	}
+
+	if err := checkRanks(0, rating); err != nil {
+		return err
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	return editLinks(ctx, store, ids, "Rated", func(link *links.Link) {
+		link.Rating = rating
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(rateCmd)
+}
@@ -1,10 +1,10 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
+	"context"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var rootCmd = &cobra.Command{
@@ -29,6 +29,14 @@ into 'default' list into database in $XDG_DATA_HOME/linkman/data.db.
 
 Later you can invoke 'list' command to print links that you've saved.
 
+Defaults such as the list, output format and database path are
+taken from $XDG_CONFIG_HOME/linkman/config.toml and LINKMAN_*
+environment variables, see 'linkman config --help'.
+
+Use --db to point at another database, e.g. in a synced folder,
+or --profile to keep separate sets of links, e.g. work and
+personal ones, see 'linkman profiles --help'.
+
 Examples on how source is calculated:
 
 | url               | source        |
@@ -36,28 +44,91 @@ Examples on how source is calculated:
 | youtube.com       | youtube       |
 | stackoverflow.com | stackoverflow |
 | domain.co.uk      | domain        |
+
+Exit codes:
+
+ 0 - success
+ 1 - unexpected error
+ 3 - data directory can't be created
+ 4 - invalid URL
+ 5 - URL already exists, 'add' reports it only with --strict
+ 6 - page can't be fetched
+ 7 - link or saved search not found
+ 130 - interrupted by SIGINT or SIGTERM, unfinished changes are rolled back
 `,
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	PersistentPreRunE: loadConfig,
 	//	Run: func(cmd *cobra.Command, args []string) { },
 }
 
 var dataPath string
+var defaultPath string
+var dbFlag = ""
+var profileFlag = ""
 
 //Execute is the entry point into the application.
 //It configures and starts the execute of root command
 //which in turn passes the execution to underying commands.
-func Execute(path string, args []string) {
+//Database at path is used unless --db, --profile or
+//configuration points at another one, profiles are kept
+//next to it. Empty path stands for the database in
+//XDG_DATA_HOME, which is created only when it's used.
+//Returned error can be told apart using errors.Is
+//and Err* values, e.g. ErrDuplicate.
+func Execute(path string, args []string) error {
+	return ExecuteContext(context.Background(), path, args)
+}
+
+//ExecuteContext is the same as Execute, but commands stop
+//their work and roll back unfinished changes when ctx is done.
+func ExecuteContext(ctx context.Context, path string, args []string) error {
 	dataPath = path
+	defaultPath = path
+	resetFlags(rootCmd)
 	rootCmd.SetArgs(args)
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	return rootCmd.ExecuteContext(ctx)
 }
 
-func die(msg string, err error) {
-	fmt.Fprintln(os.Stderr, fmt.Sprintf("%s: %s", msg, err))
-	os.Exit(1)
+//commandContext returns context the command is executed with.
+//Subcommands keep the context of the first execution, so
+//it's taken from the root command which gets it every time.
+func commandContext(cmd *cobra.Command) context.Context {
+	return cmd.Root().Context()
+}
+
+//resetFlags brings flags of the command and its subcommands
+//back to defaults, so values set by previous execution or
+//taken from configuration don't leak into the next one.
+func resetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if !flag.Changed && flag.Value.String() == flag.DefValue {
+			return
+		}
+
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	})
+
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&configPath,
+		"config", "", "",
+		"Configuration file, $XDG_CONFIG_HOME/linkman/config.toml by default")
+
+	rootCmd.PersistentFlags().StringVarP(&dbFlag,
+		"db", "", "",
+		"Links database, $XDG_DATA_HOME/linkman/data.db by default")
+
+	rootCmd.PersistentFlags().StringVarP(&profileFlag,
+		"profile", "", "",
+		"Profile which database to use, see 'linkman profiles'")
 }
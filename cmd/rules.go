@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dikeert/linkman/linkman"
+	"github.com/dikeert/linkman/urls"
+
+	"github.com/spf13/cobra"
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Debugs rules added links are routed and sourced by",
+	Long: `Links added without --list, by 'add' as well as by REST API,
+are routed by rules from settings. The first rule which conditions
+all match the link puts it into its list, adds its tags, gives
+it priority, unless one is given, and makes its title.
+
+Conditions of a rule:
+
+ - source: source of the link, e.g. youtube
+ - host: glob host of the URL matches, e.g. *.arxiv.org
+ - path: regular expression path of the URL matches
+ - title: regular expression title of the link matches
+
+Rules without conditions match every link. Changes a rule makes:
+
+ - list: list the link is added to
+ - tags: tags the link gets
+ - priority: priority the link gets, from 0 to 3
+ - title-template: template title is made with, it's executed with
+   the link as --format of 'list' is and has the same functions,
+   e.g. '{{.Title | truncate 40}} ({{host .URL}})'
+
+Example config.toml:
+
+[[rules]]
+name = "videos"
+source = "youtube"
+list = "watch"
+tags = ["video"]
+
+[[rules]]
+name = "papers"
+host = "*arxiv.org"
+path = "^/(abs|pdf)/"
+list = "papers"
+priority = 1
+
+Source of a link is the second (or third) level domain name of
+its URL, IPs and hosts without public suffix, e.g. localhost, are
+their own sources. Source rules change that: {name} matches a whole
+label of the host or a segment of the path, the source is made by
+the template given or is the usual one followed by matched values.
+Patterns without {name} are aliases, youtu.be is youtube already.
+Rules can match sources made by source rules.
+
+[[sources]]
+pattern = "github.com/{owner}"     # github/golang
+
+[[sources]]
+pattern = "{blog}.medium.com"
+source = "{blog}"
+
+Examples:
+
+linkman rules test https://youtu.be/dQw4w9WgXcQ
+linkman rules test -t 'Attention is all you need' https://arxiv.org/abs/1706.03762
+`,
+}
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test url",
+	Short: "Prints which rule routes the URL and how",
+	Long: `'test' prints which rule would route the link added for the URL
+and the link it would make, nothing is saved. The page is fetched
+for its title unless --title or --skip-title-fetch is given.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRulesTest,
+}
+
+var rulesTitle = ""
+var rulesSkipFetch = false
+
+//routing and sources are rules from settings added links
+//are routed by and their sources are calculated by.
+var routing *linkman.Rules
+var sources *urls.Sources
+
+func runRulesTest(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	var opts []linkman.AddOption
+	if rulesTitle != "" {
+		opts = append(opts, linkman.WithTitle(rulesTitle), linkman.SkipFetch())
+	} else if rulesSkipFetch {
+		opts = append(opts, linkman.SkipFetch())
+	}
+
+	link, rule, err := newClient(store).Route(ctx, args[0], opts...)
+	if err != nil {
+		return fail("Unable to route URL", err)
+	}
+
+	if rule == nil {
+		fmt.Println("No rule matches")
+	} else {
+		fmt.Printf("Matched %s\n", rule.Name)
+	}
+
+	fmt.Printf("  Source: %s\n", link.Source)
+	fmt.Printf("  Host: %s\n", link.URL.Hostname())
+	fmt.Printf("  Title: %s\n", link.Title)
+	fmt.Printf("  List: %s\n", link.List)
+	fmt.Printf("  Tags: %s\n", strings.Join(link.Tags, ", "))
+	fmt.Printf("  Priority: %d\n", link.Priority)
+
+	return nil
+}
+
+//sourceRules prepares rules from settings sources
+//of added links are calculated by.
+func sourceRules() (*urls.Sources, error) {
+	configured, err := settings.SourceRules()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]urls.SourceRule, 0, len(configured))
+	for _, rule := range configured {
+		rules = append(rules, urls.SourceRule{Pattern: rule.Pattern, Source: rule.Source})
+	}
+
+	return urls.NewSources(rules...)
+}
+
+//routingRules prepares rules from settings links
+//added without a list are routed by.
+func routingRules() (*linkman.Rules, error) {
+	configured, err := settings.Rules()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]linkman.Rule, 0, len(configured))
+	for _, rule := range configured {
+		rules = append(rules, linkman.Rule{
+			Name:          rule.Name,
+			Source:        rule.Source,
+			Host:          rule.Host,
+			Path:          rule.Path,
+			Title:         rule.Title,
+			List:          rule.List,
+			Tags:          rule.Tags,
+			Priority:      rule.Priority,
+			TitleTemplate: rule.TitleTemplate,
+		})
+	}
+
+	return linkman.NewRules(rules, templateFuncs)
+}
+
+func init() {
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesTestCmd)
+
+	rulesTestCmd.Flags().StringVarP(&rulesTitle,
+		"title", "t", "",
+		"Match the title instead of fetched one")
+	rulesTestCmd.Flags().BoolVarP(&rulesSkipFetch,
+		"skip-title-fetch", "", false,
+		"Skip fetching title")
+}
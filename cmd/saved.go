@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var savedCmd = &cobra.Command{
+	Use:   "saved",
+	Short: "Manages saved searches",
+	Long: `'saved' manages saved searches, named filters stored in
+the database. Saved searches behave like virtual lists: refer to
+them as @name in 'list', 'archive' and other commands working
+with links.
+
+Saved search accepts the same filtering flags as 'list'
+command, put them after '--'. Flags given along with @name
+narrow the saved search down, except list, archived and snoozed
+flags, which replace those of the saved search.
+
+Examples:
+
+linkman saved add unread-rust -- -l reading -t rust
+linkman saved list
+linkman list @unread-rust
+linkman list @unread-rust --sort title - flags narrow down and
+override saved search
+linkman archive @unread-rust
+linkman saved rm unread-rust
+`,
+}
+
+var savedAddCmd = &cobra.Command{
+	Use:   "add name -- [list flags]",
+	Short: "Saves filter under the name",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSavedAdd,
+}
+
+var savedListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Prints saved searches",
+	Args:  cobra.NoArgs,
+	RunE:  runSavedList,
+}
+
+var savedRmCmd = &cobra.Command{
+	Use:   "rm name [other names]",
+	Short: "Removes saved searches",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSavedRm,
+}
+
+func runSavedAdd(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	name := strings.TrimPrefix(args[0], "@")
+	if name == "" {
+		return fail("Unable to save search", fmt.Errorf("name is empty"))
+	}
+
+	flags := pflag.NewFlagSet("saved search", pflag.ContinueOnError)
+	options := &filterFlags{}
+	options.register(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return fail("Unable to save search", err)
+	}
+
+	if flags.NArg() > 0 {
+		return fail("Unable to save search",
+			fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " ")))
+	}
+
+	filter, err := options.filter(flags, nil)
+	if err != nil {
+		return err
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	search := &links.SavedSearch{
+		Name:       name,
+		Definition: quoteArgs(args[1:]),
+		Filter:     filter,
+	}
+
+	if err := store.SaveSearch(ctx, search); err != nil {
+		return fail("Unable to save search", err)
+	}
+
+	return nil
+}
+
+func runSavedList(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	searches, err := store.SavedSearches(ctx)
+	if err != nil {
+		return fail("Unable to fetch saved searches", err)
+	}
+
+	writer := getOutputWriter()
+	for _, search := range searches {
+		fmt.Fprintf(writer, "@%s\t%s\n", search.Name, search.Definition)
+	}
+
+	return writer.Flush()
+}
+
+func runSavedRm(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range args {
+		if err := store.DeleteSearch(ctx, strings.TrimPrefix(name, "@")); err != nil {
+			return fail("Unable to remove saved search", err)
+		}
+	}
+
+	return nil
+}
+
+func quoteArgs(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'|()") {
+			arg = strconv.Quote(arg)
+		}
+
+		quoted = append(quoted, arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+func init() {
+	rootCmd.AddCommand(savedCmd)
+	savedCmd.AddCommand(savedAddCmd, savedListCmd, savedRmCmd)
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search query",
+	Short: "Finds links by words in title, description, notes and page text",
+	Long: `'search' looks up links using full-text index built over
+titles, descriptions and text of the pages behind the links,
+their notes and annotations.
+
+Results are ordered by relevance, best matches first.
+Words of the query found in the snippet are highlighted.
+
+You can specify output format for results. Available fields:
+
+ - ID, Source, Title, URL, List: same as in 'list' command
+ - Score: relevance of the link to the query
+ - Snippet: part of the page text that matches the query
+
+Default output format:
+
+ID:	{{.ID}}
+Title:	{{.Title}}
+URL:	{{.URL}}
+	{{.Snippet}}
+
+Links added before full-text search was available are not
+indexed, run 'linkman search --reindex' once to index them.
+
+Examples:
+
+linkman search "bolt transactions"
+linkman search -l reading -n 5 rust
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSearch,
+}
+
+const defaultSearchTemplate = `
+ID:	{{.ID}}
+Title:	{{.Title}}
+URL:	{{.URL}}
+	{{.Snippet}}
+`
+
+const ( // highlighting of matched words in terminal
+	highlightStart = "\x1b[1m"
+	highlightEnd   = "\x1b[0m"
+)
+
+var searchFormat = defaultSearchTemplate
+var searchList = "*"
+var searchArchived = false
+var searchSnoozed = false
+var searchLimit = 0
+var rebuildIndex = false
+
+//searchOutput is what search output template is executed against.
+type searchOutput struct {
+	links.Link
+	Score   float64
+	Snippet string
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	if rebuildIndex {
+		if err := store.Reindex(ctx); err != nil {
+			return fail("Unable to rebuild search index", err)
+		}
+	}
+
+	if len(args) == 0 {
+		if !rebuildIndex {
+			return fail("Unable to search", fmt.Errorf("query is empty"))
+		}
+		return nil
+	}
+
+	tpl, err := parseOutputTemplate("search template", searchFormat)
+	if err != nil {
+		return err
+	}
+
+	results, err := findResults(ctx, store, args[0])
+	if err != nil {
+		return err
+	}
+
+	writer := getOutputWriter()
+	highlight := isTerminal(os.Stdout)
+	for _, result := range results {
+		printResult(writer, tpl, result, highlight)
+	}
+
+	return writer.Flush()
+}
+
+func findResults(ctx context.Context, store links.Store, query string) ([]links.SearchResult, error) {
+	conds := []links.FilterCondition{links.FromList(searchList)}
+	if !searchArchived {
+		conds = append(conds, links.NoArchived())
+	}
+
+	if !searchSnoozed {
+		conds = append(conds, links.NoSnoozed())
+	}
+
+	results, err := store.Search(ctx, query, links.NewFilter(conds...))
+	if err != nil {
+		return nil, fail("Unable to search links", err)
+	}
+
+	if searchLimit > 0 && len(results) > searchLimit {
+		results = results[:searchLimit]
+	}
+
+	return results, nil
+}
+
+func printResult(writer *tabwriter.Writer,
+	tpl *template.Template,
+	result links.SearchResult,
+	highlight bool) {
+
+	snippet := result.Snippet
+	if highlight {
+		snippet = highlightMatches(result.Snippet, result.Matches)
+	}
+
+	output := searchOutput{
+		Link:    result.Link,
+		Score:   result.Score,
+		Snippet: snippet,
+	}
+
+	if err := tpl.Execute(writer, output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error pringing link: %s\n", err)
+	}
+}
+
+func highlightMatches(text string, matches [][2]int) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		b.WriteString(text[last:match[0]])
+		b.WriteString(highlightStart)
+		b.WriteString(text[match[0]:match[1]])
+		b.WriteString(highlightEnd)
+		last = match[1]
+	}
+	b.WriteString(text[last:])
+
+	return b.String()
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringVarP(&searchFormat,
+		"format", "f",
+		defaultSearchTemplate,
+		"Output template or @name of a format. Available fields are: ID, URL, Source, Title, List, Score, Snippet")
+
+	searchCmd.Flags().StringVarP(&searchList,
+		"list", "l", "*",
+		"Search only links from specified list")
+
+	searchCmd.Flags().BoolVarP(&searchArchived,
+		"archived", "a", false,
+		"Include archived links")
+
+	searchCmd.Flags().BoolVarP(&searchSnoozed,
+		"include-snoozed", "", false,
+		"Include snoozed links")
+
+	searchCmd.Flags().IntVarP(&searchLimit,
+		"limit", "n", 0,
+		"Show at most that many results")
+
+	searchCmd.Flags().BoolVarP(&rebuildIndex,
+		"reindex", "", false,
+		"Rebuild search index before searching")
+}
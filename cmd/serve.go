@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/dikeert/linkman/server"
+
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves links over JSON REST API and web UI",
+	Long: `'serve' starts HTTP server exposing links over JSON REST API
+and minimal web UI.
+
+Endpoints:
+
+GET    /links              - links, accepts the same filters as 'list':
+                             list, source, title, tag, q, archived
+                             (false, true or all), sort, reverse,
+                             limit and offset query parameters
+POST   /links              - adds a link, body: {"url": "...", "title": "...",
+                             "list": "...", "tags": [...], "skipFetch": false,
+                             "force": false}
+GET    /links/{id}         - prints a link
+PATCH  /links/{id}         - changes a link, body: {"title": "...",
+                             "list": "...", "tags": [...], "archived": true}
+POST   /links/{id}/archive - archives a link
+GET    /lists              - prints lists with numbers of links in them
+
+Web UI is served on the rest of paths: open / in a browser to
+browse lists, search, add and archive links, /bookmarklet has
+bookmarklets adding current page into a list.
+
+API requests should carry the token in "Authorization: Bearer <token>"
+header. The token is taken from serve.token setting, put it into
+config.toml or LINKMAN_SERVE_TOKEN environment variable, so it
+doesn't show up in the list of processes. Use --no-auth to serve
+without it. Web UI asks for the token once and keeps it in a cookie.
+
+Examples:
+
+linkman config set serve.token secret
+linkman serve --listen :8080
+curl -H 'Authorization: Bearer secret' 'localhost:8080/links?list=reading&q=rust'
+`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+var serveOpts = struct {
+	listen string
+	noAuth bool
+}{}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	token := settings.ServeToken()
+	if token == "" && !serveOpts.noAuth {
+		return fail("Unable to start server",
+			fmt.Errorf("token is not set, use serve.token setting or --no-auth"))
+	}
+
+	if serveOpts.noAuth {
+		token = ""
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:    serveOpts.listen,
+		Handler: server.New(store, token, clientOptions()...),
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", serveOpts.listen)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fail("Unable to start server", err)
+	}
+
+	return ctx.Err()
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVarP(&serveOpts.listen,
+		"listen", "", "localhost:8080",
+		"Address to listen on")
+
+	serveCmd.Flags().BoolVarP(&serveOpts.noAuth,
+		"no-auth", "", false,
+		"Serve without authentication")
+}
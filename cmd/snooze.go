@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var snoozeCmd = &cobra.Command{
+	Use:   "snooze id [another id]... when",
+	Short: "Hides links until later",
+	Long: `'snooze' hides links with specified IDs from 'list' and other
+commands until the given time and sets a reminder at that time,
+'linkman due' prints links which reminders are due.
+
+Time is either a delay: 12h, 3d, 2w, 1mo, 1y, or a date in local
+time: 2006-01-02 or '2006-01-02 15:04', it has to be in the future.
+
+Use --no-remind to snooze without a reminder and --clear to
+show links again and clear their reminders. Snoozed links are
+shown with --include-snoozed or --snoozed flags of 'list', 'search'
+shows them with --include-snoozed and REST API with snoozed=all.
+
+Examples:
+
+linkman snooze 42 2w
+linkman snooze 42 43 2026-11-01 --no-remind
+linkman snooze 42 --clear
+linkman list --snoozed --sort snoozed -f '{{.ID}} {{.SnoozedUntil | date "Jan 2"}} {{.Title}}\n'
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSnooze,
+}
+
+var snoozeNoRemind = false
+var snoozeClear = false
+
+func runSnooze(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	if !snoozeClear && len(args) < 2 {
+		return fail("Unable to snooze links", fmt.Errorf("specify IDs and time"))
+	}
+
+	var until time.Time
+	if !snoozeClear {
+		var err error
+		now := time.Now()
+		if until, err = links.ParseTime(args[len(args)-1], now); err != nil {
+			return fail("Unable to snooze links", err)
+		}
+
+		if !until.After(now) {
+			return fail("Unable to snooze links",
+				fmt.Errorf("%s is in the past", until.Format("2006-01-02 15:04")))
+		}
+
+		args = args[:len(args)-1]
+	}
+
+	ids, err := parseIDs(args)
+	if err != nil {
+		return err
+	}
+
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	if snoozeClear {
+		return editLinks(ctx, store, ids, "Unsnoozed", func(link *links.Link) {
+			link.Unsnooze()
+		})
+	}
+
+	fmt.Printf("Snoozing until %s\n", until.Format("2006-01-02 15:04"))
+	return editLinks(ctx, store, ids, "Snoozed", func(link *links.Link) {
+		link.Snooze(until, !snoozeNoRemind)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(snoozeCmd)
+
+	snoozeCmd.Flags().BoolVarP(&snoozeNoRemind,
+		"no-remind", "", false,
+		"Do not set a reminder")
+
+	snoozeCmd.Flags().BoolVarP(&snoozeClear,
+		"clear", "", false,
+		"Show links again and clear their reminders")
+}
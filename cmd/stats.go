@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [@saved-search]",
+	Short: "Prints statistics about links",
+	Long: `'stats' prints totals per list and per source, number of
+archived and unarchived links, links added and archived per week
+and the oldest unread link.
+
+By default all links from all lists are taken into account,
+filtering flags of 'list' command narrow that down.
+
+Examples:
+
+linkman stats
+linkman stats -l reading
+linkman stats --json
+`,
+	Args: savedSearchArg,
+	RunE: runStats,
+}
+
+var statsFilter = &filterFlags{}
+var statsJSON = false
+var statsWeeks = 8
+
+func runStats(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	conds, err := statsFilter.conditions(cmd.Flags(), true)
+	if err != nil {
+		return err
+	}
+
+	query, err := parseQuery(statsFilter.query)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("list") && !usesField(query, "list") {
+		conds = append(conds, links.FromList("*"))
+	}
+
+	if len(args) > 0 {
+		saved, err := findSavedSearch(ctx, store, args[0])
+		if err != nil {
+			return err
+		}
+
+		conds = append(conds, links.FromFilter(saved.Filter))
+	}
+
+	found, err := store.FindLinks(ctx, links.NewFilter(conds...))
+	if err != nil {
+		return fail("Unable to fetch links", err)
+	}
+
+	stats := links.NewStats(found)
+	if statsWeeks > 0 && len(stats.Weeks) > statsWeeks {
+		stats.Weeks = stats.Weeks[len(stats.Weeks)-statsWeeks:]
+	}
+
+	if statsJSON {
+		return printStatsJSON(stats)
+	}
+
+	printStatsTable(stats)
+	return nil
+}
+
+func printStatsJSON(stats *links.Stats) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(stats); err != nil {
+		return fail("Unable to print stats", err)
+	}
+
+	return nil
+}
+
+func printStatsTable(stats *links.Stats) {
+	writer := getOutputWriter()
+	fmt.Fprintf(writer, "Total:\t%d\n", stats.Total)
+	fmt.Fprintf(writer, "Unarchived:\t%d\n", stats.Unarchived)
+	fmt.Fprintf(writer, "Archived:\t%d\n", stats.Archived)
+	if oldest := stats.OldestUnread; oldest != nil {
+		fmt.Fprintf(writer, "Oldest unread:\t%d %s", oldest.ID, oldest.Title)
+		if oldest.Created != nil {
+			fmt.Fprintf(writer, " (added %s)", oldest.Created.Format("2006-01-02"))
+		}
+		fmt.Fprintln(writer)
+	}
+	writer.Flush()
+
+	printCountsTable("List", stats.Lists)
+	printCountsTable("Source", stats.Sources)
+
+	if len(stats.Weeks) > 0 {
+		writer = newTableWriter()
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "Week\tAdded\tArchived")
+		for _, week := range stats.Weeks {
+			fmt.Fprintf(writer, "%s\t%d\t%d\n", week.Week, week.Added, week.Archived)
+		}
+		writer.Flush()
+	}
+}
+
+func printCountsTable(title string, counts map[string]links.Counts) {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writer := newTableWriter()
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "%s\tTotal\tUnarchived\tArchived\n", title)
+	for _, name := range names {
+		c := counts[name]
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\n", name, c.Total, c.Unarchived, c.Archived)
+	}
+	writer.Flush()
+}
+
+func newTableWriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsFilter.register(statsCmd.Flags())
+
+	statsCmd.Flags().BoolVarP(&statsJSON,
+		"json", "", false,
+		"Print stats as JSON")
+
+	statsCmd.Flags().IntVarP(&statsWeeks,
+		"weeks", "w", 8,
+		"Show that many most recent weeks, 0 shows all")
+}
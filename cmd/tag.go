@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dikeert/linkman/links"
+
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag --add tag|--remove tag [id|@saved-search]...",
+	Short: "Adds or removes tags of links",
+	Long: `'tag' adds tags specified by --add and removes tags
+specified by --remove, both can be repeated. Tags are case-insensitive.
+Use 'linkman list --tag name' to show tagged links.
+` + bulkUsage + `
+Examples:
+
+linkman tag --add rust id
+linkman tag --add video --remove article -s youtube -l '*' --yes
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(addedTags) == 0 && len(removedTags) == 0 {
+			return fail("Unable to tag links", fmt.Errorf("no tags to add or remove"))
+		}
+
+		return runBulk(cmd, args, tagFlags, "tag", "Tagged", tagLinks)
+	},
+}
+
+var tagFlags = &bulkFlags{}
+var addedTags []string
+var removedTags []string
+
+func tagLinks(ctx context.Context, store links.Store, filter links.LinkFilter) ([]links.Link, error) {
+	return store.UpdateLinks(ctx, filter, func(link *links.Link) {
+		link.RemoveTags(removedTags...)
+		link.AddTags(addedTags...)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagFlags.register(tagCmd.Flags())
+	tagCmd.Flags().StringArrayVarP(&addedTags, "add", "", nil, "Tag to add")
+	tagCmd.Flags().StringArrayVarP(&removedTags, "remove", "", nil, "Tag to remove")
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/dikeert/linkman/links"
+	"github.com/dikeert/linkman/pages"
+	"github.com/dikeert/linkman/tui"
+
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browses links in the terminal",
+	Long: `'tui' shows lists in a sidebar and links of the chosen list
+in a table. Links are searched, sorted and changed in place:
+
+ tab, h, l  - switch between the sidebar and the table
+ j, k       - move up and down (arrows, ^P and ^N work too)
+ /          - search links as you type, esc clears the search
+ s, S       - change sort order, reverse it
+ A          - show or hide archived links
+ Z          - show or hide snoozed links
+ a          - archive or unarchive the link
+ e          - edit title of the link
+ m          - move the link into another list
+ r          - refresh title of the link from its page
+ o, enter   - open the link as 'open' does
+ g          - reload lists and links
+ q          - quit
+
+The list given by --list is shown first, the default list
+from settings when the flag is not given.
+
+Examples:
+
+linkman tui
+linkman tui -l reading
+`,
+	Args: cobra.NoArgs,
+	RunE: runTui,
+}
+
+var tuiList = "default"
+
+func runTui(cmd *cobra.Command, args []string) error {
+	ctx := commandContext(cmd)
+	store, err := openLinksStore(ctx, dataPath)
+	if err != nil {
+		return err
+	}
+
+	tty, err := tui.OpenTTY(ctx)
+	if err != nil {
+		return fail("Unable to open terminal", err)
+	}
+	defer tty.Close()
+
+	client := &http.Client{Timeout: settings.FetchTimeout()}
+	browser := &tui.Browser{
+		Store: store,
+		List:  tuiList,
+		Fetch: func(ctx context.Context, url *url.URL) (*pages.Page, error) {
+			return pages.FetchPage(ctx, url,
+				pages.WithClient(client),
+				pages.WithUserAgent(settings.UserAgent()))
+		},
+		Open: func(ctx context.Context, link links.Link) error {
+			return openLink(ctx, store, link, "", false, false)
+		},
+	}
+
+	if err := browser.Run(ctx, tty); err != nil {
+		return fail("Unable to browse links", err)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+
+	tuiCmd.Flags().StringVarP(&tuiList,
+		"list", "l", "default",
+		"List to show first, '*' for all lists")
+}
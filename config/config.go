@@ -0,0 +1,456 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/pelletier/go-toml"
+	"github.com/spf13/viper"
+)
+
+const configDir = "linkman"
+const configFile = "config.toml"
+
+//Keys of settings, environment variables are named after them,
+//e.g. LINKMAN_FETCH_TIMEOUT for fetch.timeout.
+const (
+	//List is the list links are added to and listed from.
+	List = "list"
+	//Format is output template of 'list' command or @name of a format.
+	Format = "format"
+	//FetchTimeout limits how long fetching of a page takes.
+	FetchTimeout = "fetch.timeout"
+	//FetchUserAgent is User-Agent pages are fetched with.
+	FetchUserAgent = "fetch.user-agent"
+	//DB is path to the links database.
+	DB = "db"
+	//Profile is the profile which database is used unless DB is set.
+	Profile = "profile"
+	//Opener is command links are opened with.
+	Opener = "opener"
+	//ServeToken is the token clients of 'serve' authenticate with.
+	ServeToken = "serve.token"
+	//Formats holds named output templates, formats.name = "template".
+	Formats = "formats"
+	//Openers holds commands links from particular lists
+	//are opened with, openers.list = "command".
+	Openers = "openers"
+	//Retention holds retention policies of lists,
+	//retention.list.keep = 100 and so on, see Policy.
+	Retention = "retention"
+	//Rules holds rules links added without a list are
+	//routed by, an array of tables, see Rule.
+	Rules = "rules"
+	//Sources holds rules sources of links are calculated
+	//by, an array of tables, see SourceRule.
+	Sources = "sources"
+)
+
+//Settings of a retention policy, retention.list.setting.
+const (
+	//ArchiveAfter is how many days unread links are kept.
+	ArchiveAfter = "archive-after"
+	//DeleteAfter is how many days archived links are kept.
+	DeleteAfter = "delete-after"
+	//Keep is how many unread links a list holds at most.
+	Keep = "keep"
+)
+
+var policyKeys = []string{ArchiveAfter, DeleteAfter, Keep}
+
+var keys = []string{List, Format, FetchTimeout, FetchUserAgent, DB, Profile, Opener, ServeToken}
+
+//secrets are keys which values Settings hides.
+var secrets = map[string]bool{ServeToken: true}
+
+var tables = []string{Formats, Openers}
+
+var defaults = map[string]string{
+	List:           "default",
+	FetchTimeout:   "30s",
+	FetchUserAgent: "",
+	Format:         "",
+	DB:             "",
+	Profile:        "",
+	Opener:         "xdg-open",
+	ServeToken:     "",
+}
+
+//Config holds settings taken from environment variables,
+//configuration file and built-in defaults, in that order.
+type Config struct {
+	path     string
+	settings *viper.Viper
+}
+
+//DefaultPath returns path to configuration file
+//in XDG_CONFIG_HOME.
+func DefaultPath() string {
+	return filepath.Join(xdg.ConfigHome, configDir, configFile)
+}
+
+//Load reads configuration file located at path, missing
+//file is the same as empty one. Settings aren't validated,
+//so invalid ones can still be shown and fixed, see Validate.
+func Load(path string) (*Config, error) {
+	me := &Config{
+		path:     path,
+		settings: viper.New(),
+	}
+
+	for key, value := range defaults {
+		me.settings.SetDefault(key, value)
+	}
+
+	me.settings.SetEnvPrefix("linkman")
+	me.settings.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	me.settings.AutomaticEnv()
+
+	me.settings.SetConfigFile(path)
+	me.settings.SetConfigType("toml")
+	if _, err := os.Stat(path); err == nil {
+		if err := me.settings.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("Unable to read config %s: %s", path, err)
+		}
+	}
+
+	return me, nil
+}
+
+//Validate checks settings whether they come from
+//the file or environment.
+func (me *Config) Validate() error {
+	value := me.settings.GetString(FetchTimeout)
+	if _, err := time.ParseDuration(value); err != nil {
+		return fmt.Errorf("Invalid %s %q: %s", FetchTimeout, value, err)
+	}
+
+	if _, err := me.Policies(); err != nil {
+		return err
+	}
+
+	if _, err := me.Rules(); err != nil {
+		return err
+	}
+
+	_, err := me.SourceRules()
+	return err
+}
+
+//Path returns path to configuration file.
+func (me *Config) Path() string {
+	return me.path
+}
+
+//Get returns value of the setting, key is one of the
+//constants, formats.name, openers.list or retention.list.keep
+//and other settings of a retention policy.
+func (me *Config) Get(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+
+	return me.settings.GetString(key), nil
+}
+
+//Set changes the setting in configuration file and saves it,
+//environment variables still take precedence over it. Other
+//settings in the file are kept, but comments are not.
+func (me *Config) Set(key string, value string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	if strings.ToLower(key) == FetchTimeout {
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("Invalid %s: %s", key, err)
+		}
+	}
+
+	if strings.HasPrefix(strings.ToLower(key), Retention+".") {
+		if _, err := parseDays(key, value); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(me.path), 0700); err != nil {
+		return fmt.Errorf("Unable to create config directory: %s", err)
+	}
+
+	tree, err := me.readTree()
+	if err != nil {
+		return err
+	}
+
+	tree.Set(strings.ToLower(key), value)
+	text, err := tree.ToTomlString()
+	if err != nil {
+		return fmt.Errorf("Unable to write config %s: %s", me.path, err)
+	}
+
+	if err := ioutil.WriteFile(me.path, []byte(text), 0600); err != nil {
+		return fmt.Errorf("Unable to write config %s: %s", me.path, err)
+	}
+
+	me.settings.Set(key, value)
+	return nil
+}
+
+//readTree reads configuration file as it is, so keys
+//are changed without touching the rest of it.
+func (me *Config) readTree() (*toml.Tree, error) {
+	if _, err := os.Stat(me.path); os.IsNotExist(err) {
+		return toml.TreeFromMap(map[string]interface{}{})
+	}
+
+	tree, err := toml.LoadFile(me.path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read config %s: %s", me.path, err)
+	}
+
+	return tree, nil
+}
+
+//Settings returns all settings in effect, named formats,
+//openers and retention policies included, ordered by key.
+//Secrets, such as serve.token, are masked.
+func (me *Config) Settings() [][2]string {
+	var result [][2]string
+	for _, key := range keys {
+		value := me.settings.GetString(key)
+		if secrets[key] && value != "" {
+			value = "********"
+		}
+
+		result = append(result, [2]string{key, value})
+	}
+
+	for _, table := range tables {
+		values := me.settings.GetStringMapString(table)
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			result = append(result, [2]string{table + "." + name, values[name]})
+		}
+	}
+
+	for _, list := range me.policyLists() {
+		for _, name := range policyKeys {
+			key := Retention + "." + list + "." + name
+			if me.settings.IsSet(key) {
+				result = append(result, [2]string{key, me.settings.GetString(key)})
+			}
+		}
+	}
+
+	return result
+}
+
+//DefaultList returns the list links are added to and listed from.
+func (me *Config) DefaultList() string {
+	return me.settings.GetString(List)
+}
+
+//Format returns default output format of 'list' command,
+//empty when built-in one should be used.
+func (me *Config) Format() string {
+	return me.settings.GetString(Format)
+}
+
+//NamedFormat looks up output template by its name.
+func (me *Config) NamedFormat(name string) (string, bool) {
+	format, ok := me.Formats()[strings.ToLower(name)]
+	return format, ok
+}
+
+//Formats returns named output templates.
+func (me *Config) Formats() map[string]string {
+	return me.settings.GetStringMapString(Formats)
+}
+
+//FetchTimeout returns how long fetching of a page may take,
+//zero means no limit.
+func (me *Config) FetchTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(me.settings.GetString(FetchTimeout))
+	return timeout
+}
+
+//UserAgent returns User-Agent pages are fetched with,
+//empty when Go default should be used.
+func (me *Config) UserAgent() string {
+	return me.settings.GetString(FetchUserAgent)
+}
+
+//DB returns path to the links database, empty
+//when default one should be used.
+func (me *Config) DB() string {
+	return ExpandHome(me.settings.GetString(DB))
+}
+
+//Opener returns command links from the list are opened with.
+func (me *Config) Opener(list string) string {
+	if opener, ok := me.settings.GetStringMapString(Openers)[strings.ToLower(list)]; ok {
+		return opener
+	}
+
+	return me.settings.GetString(Opener)
+}
+
+//ServeToken returns the token clients of 'serve' authenticate
+//with, empty when it isn't set.
+func (me *Config) ServeToken() string {
+	return me.settings.GetString(ServeToken)
+}
+
+//Profile returns the profile which database is used,
+//empty when default one should be used.
+func (me *Config) Profile() string {
+	return me.settings.GetString(Profile)
+}
+
+//Policy is retention policy of a list, zero turns a rule off.
+type Policy struct {
+	//ArchiveAfter is how many days unread links are kept.
+	ArchiveAfter int
+	//DeleteAfter is how many days archived links are kept.
+	DeleteAfter int
+	//Keep is how many unread links the list holds at most.
+	Keep int
+}
+
+//Policies returns retention policies by list, list
+//names are lower case as keys of settings are.
+func (me *Config) Policies() (map[string]Policy, error) {
+	result := map[string]Policy{}
+	for _, list := range me.policyLists() {
+		values := map[string]int{}
+		for _, name := range policyKeys {
+			key := Retention + "." + list + "." + name
+			value, err := parseDays(key, me.settings.GetString(key))
+			if err != nil {
+				return nil, err
+			}
+
+			values[name] = value
+		}
+
+		result[list] = Policy{
+			ArchiveAfter: values[ArchiveAfter],
+			DeleteAfter:  values[DeleteAfter],
+			Keep:         values[Keep],
+		}
+	}
+
+	return result, nil
+}
+
+func (me *Config) policyLists() []string {
+	var lists []string
+	for list := range me.settings.GetStringMap(Retention) {
+		lists = append(lists, list)
+	}
+
+	sort.Strings(lists)
+	return lists
+}
+
+//parseDays parses setting of a retention policy,
+//a number of days or links, empty is zero.
+func parseDays(key string, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("Invalid %s %q: should be zero or more", key, value)
+	}
+
+	return number, nil
+}
+
+//Rule is a routing rule of added links, see linkman.Rule.
+type Rule struct {
+	Name          string
+	Source        string
+	Host          string
+	Path          string
+	Title         string
+	List          string
+	Tags          []string
+	Priority      int
+	TitleTemplate string `mapstructure:"title-template"`
+}
+
+//Rules returns routing rules in the order they are defined.
+func (me *Config) Rules() ([]Rule, error) {
+	var rules []Rule
+	if err := me.settings.UnmarshalKey(Rules, &rules); err != nil {
+		return nil, fmt.Errorf("Invalid %s: %s", Rules, err)
+	}
+
+	return rules, nil
+}
+
+//SourceRule is a rule source of a link is calculated by,
+//see urls.SourceRule.
+type SourceRule struct {
+	Pattern string
+	Source  string
+}
+
+//SourceRules returns source rules in the order they are defined.
+func (me *Config) SourceRules() ([]SourceRule, error) {
+	var rules []SourceRule
+	if err := me.settings.UnmarshalKey(Sources, &rules); err != nil {
+		return nil, fmt.Errorf("Invalid %s: %s", Sources, err)
+	}
+
+	return rules, nil
+}
+
+func checkKey(key string) error {
+	key = strings.ToLower(key)
+	if parts := strings.Split(key, "."); parts[0] == Retention && len(parts) == 3 && parts[1] != "" {
+		for _, name := range policyKeys {
+			if parts[2] == name {
+				return nil
+			}
+		}
+	}
+
+	for _, table := range tables {
+		if strings.HasPrefix(key, table+".") && len(key) > len(table)+1 {
+			return nil
+		}
+	}
+
+	for _, known := range keys {
+		if key == known {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unknown setting %q, known are %s, %s.name, %s.list and %s.list.%s",
+		key, strings.Join(keys, ", "), Formats, Openers, Retention, strings.Join(policyKeys, "|"))
+}
+
+//ExpandHome replaces leading ~ of the path with home directory.
+func ExpandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(xdg.Home, path[1:])
+	}
+
+	return path
+}
@@ -2,23 +2,23 @@ package data
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/adrg/xdg"
 )
 
-//EnsureDataHome make sture that directory dir exists at
-//location XDG_DATA_HOME.
-func EnsureDataHome(dir string) error {
-	path := xdg.DataHome + dir
+//DefaultProfile is the profile stored in the default database.
+const DefaultProfile = "default"
 
-	err := os.MkdirAll(path, 0700)
-	if err != nil {
-		return fmt.Errorf("Unable to create data directory: %s", err)
-	}
+const profilesDir = "profiles"
+const profileExt = ".db"
 
-	return nil
-}
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
 
 //GetFilePath returns path to the file in directory dir
 //that is located in XDG_DATA_HOME.
@@ -33,3 +33,47 @@ func GetFilePath(dir string, file string) (string, error) {
 
 	return path, nil
 }
+
+//GetProfilePath returns path to database of the profile,
+//profiles are kept in 'profiles' directory next to
+//the default database located at path.
+func GetProfilePath(path string, profile string) (string, error) {
+	if profile == DefaultProfile {
+		return path, nil
+	}
+
+	if !profileName.MatchString(profile) {
+		return "", fmt.Errorf("Invalid profile name [%s]", profile)
+	}
+
+	dir := filepath.Join(filepath.Dir(path), profilesDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("Unable to create profiles directory: %s", err)
+	}
+
+	return filepath.Join(dir, profile+profileExt), nil
+}
+
+//Profiles returns names of profiles which databases exist
+//next to the default database located at path, ordered by name.
+func Profiles(path string) ([]string, error) {
+	profiles := []string{DefaultProfile}
+	files, err := ioutil.ReadDir(filepath.Join(filepath.Dir(path), profilesDir))
+	if os.IsNotExist(err) {
+		return profiles, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Unable to list profiles: %s", err)
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), profileExt)
+		if !file.IsDir() && name != file.Name() && name != DefaultProfile &&
+			profileName.MatchString(name) {
+
+			profiles = append(profiles, name)
+		}
+	}
+
+	sort.Strings(profiles[1:])
+	return profiles, nil
+}
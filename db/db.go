@@ -1,17 +1,32 @@
 package db
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/asdine/storm"
+	bolt "go.etcd.io/bbolt"
 )
 
+//lockTimeout is how long Open waits for the lock
+//before checking whether it was cancelled.
+const lockTimeout = 100 * time.Millisecond
+
 //Open tries open database located at specified path.
-func Open(path string) (*storm.DB, error) {
-	db, err := storm.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("Unable to open database: %s", err)
-	}
+//While other process holds the database, Open waits
+//for it until ctx is done.
+func Open(ctx context.Context, path string) (*storm.DB, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("Unable to open database: %w", err)
+		}
 
-	return db, nil
+		db, err := storm.Open(path, storm.BoltOptions(0600, &bolt.Options{Timeout: lockTimeout}))
+		if err == nil {
+			return db, nil
+		} else if err != bolt.ErrTimeout {
+			return nil, fmt.Errorf("Unable to open database: %s", err)
+		}
+	}
 }
@@ -0,0 +1,447 @@
+//Package linkman allows to use linkman from Go programs.
+//
+//Client wraps links store, URL parsing and page fetching
+//the same way linkman commands do:
+//
+//	client, err := linkman.Open(ctx, path, linkman.WithDefaultList("reading"))
+//	link, err := client.Add(ctx, "https://example.com", linkman.WithTags("go"))
+//	found, err := client.List(ctx, linkman.Filter{Query: "title:go"})
+package linkman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dikeert/linkman/links"
+	"github.com/dikeert/linkman/pages"
+	"github.com/dikeert/linkman/urls"
+)
+
+//Link is a stored bookmark.
+type Link = links.Link
+
+//SearchResult is a link found by full-text search.
+type SearchResult = links.SearchResult
+
+//Errors Client returns, use errors.Is to tell them apart.
+var (
+	//ErrDuplicate tells that link for the URL already exists.
+	ErrDuplicate = errors.New("duplicate URL")
+	//ErrInvalidURL tells that supplied URL can't be used.
+	ErrInvalidURL = errors.New("invalid URL")
+	//ErrFetchFailed tells that page behind the URL can't be fetched.
+	ErrFetchFailed = errors.New("unable to fetch page")
+	//ErrNotFound tells that requested link or saved search doesn't exist.
+	ErrNotFound = links.ErrNotFound
+)
+
+//Client manages links in a store, it's safe to use concurrently.
+type Client struct {
+	store       links.Store
+	defaultList string
+	httpClient  *http.Client
+	userAgent   string
+	rules       *Rules
+	sources     *urls.Sources
+}
+
+//Option configures Client.
+type Option func(*Client)
+
+//WithDefaultList sets the list links are added to and
+//listed from when Filter doesn't specify one, "default" by default.
+func WithDefaultList(list string) Option {
+	return func(me *Client) {
+		me.defaultList = list
+	}
+}
+
+//WithHTTPClient sets HTTP client pages are fetched with.
+func WithHTTPClient(client *http.Client) Option {
+	return func(me *Client) {
+		me.httpClient = client
+	}
+}
+
+//WithUserAgent sets User-Agent header pages are fetched with.
+func WithUserAgent(userAgent string) Option {
+	return func(me *Client) {
+		me.userAgent = userAgent
+	}
+}
+
+//WithRules routes links added without a list by the rules.
+func WithRules(rules *Rules) Option {
+	return func(me *Client) {
+		me.rules = rules
+	}
+}
+
+//WithSources calculates sources of added links by the
+//rules instead of default ones.
+func WithSources(sources *urls.Sources) Option {
+	return func(me *Client) {
+		me.sources = sources
+	}
+}
+
+//Open opens links database located at path.
+func Open(ctx context.Context, path string, opts ...Option) (*Client, error) {
+	store, err := links.OpenStore(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(store, opts...), nil
+}
+
+//New creates Client over already opened store.
+func New(store links.Store, opts ...Option) *Client {
+	me := &Client{
+		store:       store,
+		defaultList: "default",
+		httpClient:  http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(me)
+	}
+
+	return me
+}
+
+//Store returns the store Client works with.
+func (me *Client) Store() links.Store {
+	return me.store
+}
+
+//DefaultList returns the list links are added to and
+//listed from when Filter doesn't specify one.
+func (me *Client) DefaultList() string {
+	return me.defaultList
+}
+
+//AddOption configures how Client.Add creates a link.
+type AddOption func(*addOptions)
+
+type addOptions struct {
+	list      string
+	title     string
+	tags      []string
+	priority  int
+	skipFetch bool
+	force     bool
+}
+
+//InList adds the link into the list instead of default one,
+//rules don't route links added into a list.
+func InList(list string) AddOption {
+	return func(me *addOptions) {
+		me.list = list
+	}
+}
+
+//WithTitle uses the title instead of fetched one.
+func WithTitle(title string) AddOption {
+	return func(me *addOptions) {
+		me.title = title
+	}
+}
+
+//WithTags tags the link.
+func WithTags(tags ...string) AddOption {
+	return func(me *addOptions) {
+		me.tags = append(me.tags, tags...)
+	}
+}
+
+//WithPriority sets priority of the link,
+//see links.CheckPriority for allowed values.
+func WithPriority(priority int) AddOption {
+	return func(me *addOptions) {
+		me.priority = priority
+	}
+}
+
+//SkipFetch doesn't fetch the page, so the link has
+//only the title provided by WithTitle, if any.
+func SkipFetch() AddOption {
+	return func(me *addOptions) {
+		me.skipFetch = true
+	}
+}
+
+//AllowDuplicate adds the link even if link
+//for the same URL already exists.
+func AllowDuplicate() AddOption {
+	return func(me *addOptions) {
+		me.force = true
+	}
+}
+
+//Add creates new link for the URL: calculates its source, fetches the page,
+//routes the link by rules unless InList is given and saves the link.
+//Returned errors wrap ErrInvalidURL, ErrDuplicate and ErrFetchFailed.
+func (me *Client) Add(ctx context.Context, rawurl string, opts ...AddOption) (*Link, error) {
+	options, parsed, source, err := me.prepare(rawurl, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !options.force {
+		if exists, err := me.store.LinkExists(ctx, parsed); err != nil {
+			return nil, err
+		} else if exists {
+			return nil, fmt.Errorf("%w: URL %s already exists", ErrDuplicate, rawurl)
+		}
+	}
+
+	link, _, err := me.newLink(ctx, parsed, source, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := me.store.SaveLink(ctx, link); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+//Route makes the link Add would add for the URL without saving it
+//and returns the rule which routed the link, nil when none did.
+//Route doesn't check whether the URL already exists.
+func (me *Client) Route(ctx context.Context, rawurl string, opts ...AddOption) (*Link, *Rule, error) {
+	options, parsed, source, err := me.prepare(rawurl, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return me.newLink(ctx, parsed, source, options)
+}
+
+func (me *Client) prepare(rawurl string, opts []AddOption) (*addOptions, *url.URL, string, error) {
+	options := &addOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	parsed, err := urls.ParseURL(rawurl)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("%w: %s", ErrInvalidURL, err)
+	}
+
+	source, err := me.sources.Source(parsed)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("%w: %s", ErrInvalidURL, err)
+	}
+
+	if err := links.CheckPriority(options.priority); err != nil {
+		return nil, nil, "", err
+	}
+
+	return options, parsed, source, nil
+}
+
+func (me *Client) newLink(ctx context.Context,
+	url *url.URL,
+	source string,
+	options *addOptions) (*Link, *Rule, error) {
+
+	page, err := me.fetchPage(ctx, url, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	link := me.store.NewLink(url, source, page.Title, options.list)
+	link.Description = page.Description
+	link.Text = page.Text
+	link.AddTags(options.tags...)
+	link.Priority = options.priority
+	if options.list != "" {
+		return link, nil, nil
+	}
+
+	link.List = me.defaultList
+	rule := me.rules.match(link)
+	if rule == nil {
+		return link, nil, nil
+	}
+
+	if err := rule.apply(link); err != nil {
+		return nil, nil, err
+	}
+
+	return link, &rule.Rule, nil
+}
+
+func (me *Client) fetchPage(ctx context.Context,
+	url *url.URL,
+	options *addOptions) (*pages.Page, error) {
+
+	if options.skipFetch {
+		return &pages.Page{Title: options.title}, nil
+	}
+
+	page, err := pages.FetchPage(ctx, url,
+		pages.WithClient(me.httpClient),
+		pages.WithUserAgent(me.userAgent))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrFetchFailed, err)
+	}
+
+	if options.title != "" {
+		page.Title = options.title
+	} else if page.Title == "" {
+		return nil, fmt.Errorf("%w: Unable to find title tag", ErrFetchFailed)
+	}
+
+	return page, nil
+}
+
+//List returns links allowed by the filter.
+func (me *Client) List(ctx context.Context, filter Filter) ([]Link, error) {
+	linkFilter, err := me.linkFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return me.store.FindLinks(ctx, linkFilter)
+}
+
+//Count returns number of links allowed by the filter.
+func (me *Client) Count(ctx context.Context, filter Filter) (int, error) {
+	linkFilter, err := me.linkFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return me.store.CountLinks(ctx, linkFilter)
+}
+
+//Get returns the link with the ID, archived or not.
+func (me *Client) Get(ctx context.Context, id int) (*Link, error) {
+	found, err := me.List(ctx, Filter{IDs: []int{id}, IncludeArchived: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("Link with ID %d %w", id, ErrNotFound)
+	}
+
+	return &found[0], nil
+}
+
+//Search finds links by words in their titles, descriptions and
+//page text, best matches first. Filter narrows the search down,
+//empty List searches all lists, Offset and Limit page through
+//the matches.
+func (me *Client) Search(ctx context.Context, query string, filter Filter) ([]SearchResult, error) {
+	if filter.List == "" {
+		filter.List = "*"
+	}
+
+	linkFilter, err := me.linkFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := me.store.Search(ctx, query, linkFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Offset > len(results) {
+		filter.Offset = len(results)
+	}
+
+	if filter.Offset > 0 {
+		results = results[filter.Offset:]
+	}
+
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+
+	return results, nil
+}
+
+//Update changes links with the IDs in a single transaction
+//and returns changed links. When some of the links don't exist,
+//the rest are changed and returned with ErrNotFound.
+func (me *Client) Update(ctx context.Context, update func(*Link), ids ...int) ([]Link, error) {
+	changed, err := me.store.UpdateLinks(ctx, byIDs(ids), update)
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, missing(ids, changed)
+}
+
+//Archive archives links with the IDs.
+func (me *Client) Archive(ctx context.Context, ids ...int) ([]Link, error) {
+	return me.Update(ctx, func(link *Link) {
+		if !link.Archived {
+			link.Archived = true
+			link.ArchivedAt = time.Now()
+		}
+	}, ids...)
+}
+
+//Move puts links with the IDs into the list.
+func (me *Client) Move(ctx context.Context, list string, ids ...int) ([]Link, error) {
+	return me.Update(ctx, func(link *Link) {
+		link.List = list
+	}, ids...)
+}
+
+//Tag adds and removes tags of links with the IDs.
+func (me *Client) Tag(ctx context.Context, add []string, remove []string, ids ...int) ([]Link, error) {
+	return me.Update(ctx, func(link *Link) {
+		link.RemoveTags(remove...)
+		link.AddTags(add...)
+	}, ids...)
+}
+
+//Delete removes links with the IDs for good.
+func (me *Client) Delete(ctx context.Context, ids ...int) ([]Link, error) {
+	deleted, err := me.store.DeleteLinks(ctx, byIDs(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, missing(ids, deleted)
+}
+
+func byIDs(ids []int) links.LinkFilter {
+	return links.NewFilter(links.WithIDs(ids...), links.IncludeArchived())
+}
+
+func missing(ids []int, found []Link) error {
+	seen := map[int]bool{}
+	for _, link := range found {
+		seen[link.ID] = true
+	}
+
+	var absent []int
+	for _, id := range ids {
+		if !seen[id] {
+			absent = append(absent, id)
+		}
+	}
+
+	if len(absent) > 0 {
+		return fmt.Errorf("Links with IDs %v %w", absent, ErrNotFound)
+	}
+
+	return nil
+}
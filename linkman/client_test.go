@@ -0,0 +1,228 @@
+package linkman_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/dikeert/linkman/linkman"
+	"github.com/dikeert/linkman/links"
+	"github.com/dikeert/linkman/urls"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	client, cleanup := openClient(t, linkman.WithDefaultList("reading"))
+	defer cleanup()
+
+	link, err := client.Add(ctx, "https://example.com/go",
+		linkman.SkipFetch(),
+		linkman.WithTitle("Go tour"),
+		linkman.WithTags("Go"))
+	if assert.NoError(err) {
+		assert.Equal("reading", link.List, "Should add into default list")
+		assert.Equal("example", link.Source)
+		assert.Equal([]string{"go"}, link.Tags)
+	}
+
+	_, err = client.Add(ctx, "https://example.com/go", linkman.SkipFetch())
+	assert.True(errors.Is(err, linkman.ErrDuplicate), "Should reject duplicate: %v", err)
+
+	_, err = client.Add(ctx, "not-a-url", linkman.SkipFetch())
+	assert.True(errors.Is(err, linkman.ErrInvalidURL), "Should reject invalid URL: %v", err)
+
+	_, err = client.Add(ctx, "https://example.org/rust",
+		linkman.SkipFetch(),
+		linkman.WithTitle("Rust book"),
+		linkman.InList("watch"))
+	assert.NoError(err)
+
+	found, err := client.List(ctx, linkman.Filter{})
+	assert.NoError(err)
+	assert.Equal(1, len(found), "Should list default list only")
+
+	found, err = client.List(ctx, linkman.Filter{Query: "list:* title:rust"})
+	assert.NoError(err)
+	assert.Equal(1, len(found), "Should filter by query")
+
+	archived, err := client.Archive(ctx, 1, 42)
+	assert.True(errors.Is(err, linkman.ErrNotFound), "Should report missing link: %v", err)
+	assert.Equal(1, len(archived), "Should archive existing link")
+
+	count, err := client.Count(ctx, linkman.Filter{List: "*"})
+	assert.NoError(err)
+	assert.Equal(1, count, "Should hide archived links")
+
+	_, err = client.Get(ctx, 42)
+	assert.True(errors.Is(err, linkman.ErrNotFound))
+
+	_, err = client.Store().FindLinks(ctx, links.NewFilter(links.FromFilter(nil)))
+	assert.Error(err, "Should reject unsupported filter")
+	_, err = client.Store().CountLinks(ctx, nil)
+	assert.Error(err, "Should reject nil filter")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = client.Add(cancelled, "https://example.net/")
+	assert.True(errors.Is(err, context.Canceled), "Should stop when cancelled: %v", err)
+}
+
+func TestSearch(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	client, cleanup := openClient(t)
+	defer cleanup()
+
+	for _, title := range []string{"Go tour", "Go in action", "Learning Go"} {
+		_, err := client.Add(ctx, "https://example.com/"+strings.ReplaceAll(title, " ", "-"),
+			linkman.SkipFetch(),
+			linkman.WithTitle(title))
+		assert.NoError(err)
+	}
+
+	all, err := client.Search(ctx, "go", linkman.Filter{})
+	if !assert.NoError(err) || !assert.Equal(3, len(all)) {
+		return
+	}
+
+	var paged []int
+	for offset := 0; offset < 4; offset++ {
+		page, err := client.Search(ctx, "go", linkman.Filter{Offset: offset, Limit: 1})
+		assert.NoError(err)
+		for _, result := range page {
+			paged = append(paged, result.Link.ID)
+		}
+	}
+
+	var expected []int
+	for _, result := range all {
+		expected = append(expected, result.Link.ID)
+	}
+
+	assert.Equal(expected, paged, "Should page through matches by offset")
+}
+
+func TestRules(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	_, err := linkman.NewRules([]linkman.Rule{{Path: "("}}, nil)
+	assert.Error(err, "Should reject invalid path")
+	_, err = linkman.NewRules([]linkman.Rule{{Priority: 7}}, nil)
+	assert.Error(err, "Should reject invalid priority")
+
+	_, err = linkman.NewRules([]linkman.Rule{{TitleTemplate: "{{.Title | shout}}"}}, nil)
+	assert.Error(err, "Should reject unknown function")
+
+	funcs := template.FuncMap{"shout": strings.ToUpper}
+	rules, err := linkman.NewRules([]linkman.Rule{
+		{Name: "videos", Source: "youtube", List: "watch", Tags: []string{"Video"}},
+		{Host: "*arxiv.org", Path: "^/abs/", Title: "(?i)attention",
+			List: "papers", Priority: 2, TitleTemplate: "{{.Title | shout}} [{{.Source}}]"},
+		{Host: "*arxiv.org", List: "later"},
+	}, funcs)
+	if !assert.NoError(err) {
+		return
+	}
+
+	client, cleanup := openClient(t, linkman.WithRules(rules))
+	defer cleanup()
+
+	link, err := client.Add(ctx, "https://www.youtube.com/watch?v=1", linkman.SkipFetch())
+	if assert.NoError(err) {
+		assert.Equal("watch", link.List)
+		assert.Equal([]string{"video"}, link.Tags)
+	}
+
+	link, rule, err := client.Route(ctx, "https://arxiv.org/abs/1706.03762",
+		linkman.SkipFetch(), linkman.WithTitle("Attention is all you need"))
+	if assert.NoError(err) && assert.NotNil(rule) {
+		assert.Equal("rule 2", rule.Name, "Should name rules by position")
+		assert.Equal("papers", link.List)
+		assert.Equal(2, link.Priority)
+		assert.Equal("ATTENTION IS ALL YOU NEED [arxiv]", link.Title, "Should use given functions")
+	}
+
+	link, rule, err = client.Route(ctx, "https://export.arxiv.org/pdf/1706.03762",
+		linkman.SkipFetch(), linkman.WithPriority(1))
+	if assert.NoError(err) && assert.NotNil(rule) {
+		assert.Equal("rule 3", rule.Name, "Should apply the first matching rule")
+		assert.Equal("later", link.List)
+		assert.Equal(1, link.Priority, "Should keep given priority")
+	}
+
+	link, rule, err = client.Route(ctx, "https://www.youtube.com/watch?v=2",
+		linkman.SkipFetch(), linkman.InList("music"))
+	if assert.NoError(err) {
+		assert.Nil(rule, "Should not route links added into a list")
+		assert.Equal("music", link.List)
+	}
+
+	link, rule, err = client.Route(ctx, "https://example.com/", linkman.SkipFetch())
+	if assert.NoError(err) {
+		assert.Nil(rule)
+		assert.Equal("default", link.List)
+	}
+}
+
+func TestSources(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	_, err := urls.NewSources(urls.SourceRule{Pattern: "a{b}.com"})
+	assert.Error(err, "Should reject partial placeholder")
+	_, err = urls.NewSources(urls.SourceRule{Pattern: "x.com", Source: "{y}"})
+	assert.Error(err, "Should reject unknown placeholder")
+
+	sources, err := urls.NewSources(
+		urls.SourceRule{Pattern: "github.com/{owner}"},
+		urls.SourceRule{Pattern: "{blog}.medium.com", Source: "{blog}"},
+		urls.SourceRule{Pattern: "go.dev", Source: "golang"},
+	)
+	if !assert.NoError(err) {
+		return
+	}
+
+	client, cleanup := openClient(t, linkman.WithSources(sources))
+	defer cleanup()
+
+	for rawurl, source := range map[string]string{
+		"https://github.com/Golang/go":    "github/golang",
+		"https://github.com/":             "github",
+		"https://alice.medium.com/post":   "alice",
+		"https://medium.com/post":         "medium",
+		"https://go.dev/blog":             "golang",
+		"https://youtu.be/dQw4w9WgXcQ":    "youtube",
+		"http://localhost:8080/":          "localhost",
+		"http://192.168.1.10/admin":       "192.168.1.10",
+		"http://wiki/Main_Page":           "wiki",
+		"https://docs.example.co.uk/page": "example",
+	} {
+		link, _, err := client.Route(ctx, rawurl, linkman.SkipFetch())
+		if assert.NoError(err, rawurl) {
+			assert.Equal(source, link.Source, rawurl)
+		}
+	}
+}
+
+func openClient(t *testing.T, opts ...linkman.Option) (*linkman.Client, func()) {
+	tmpfile, err := ioutil.TempFile("", "linkman.*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+
+	client, err := linkman.Open(context.Background(), tmpfile.Name(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, func() { os.Remove(tmpfile.Name()) }
+}
@@ -0,0 +1,98 @@
+package linkman
+
+import (
+	"github.com/dikeert/linkman/links"
+)
+
+//Filter selects links, it mirrors flags of 'linkman list'.
+//Zero Filter selects non-archived links from the default list.
+type Filter struct {
+	//Query is a filter expression, see links.Query for syntax.
+	Query string
+	//List selects links from the list, "*" selects any list and
+	//empty selects the default list, unless Query or IDs say otherwise.
+	List   string
+	Source string
+	//Title selects links which title contains it.
+	Title string
+	Tag   string
+	IDs   []int
+	//MinPriority selects links which priority is at least it.
+	MinPriority int
+	//Rated selects only links that have been rated.
+	Rated bool
+
+	IncludeArchived bool
+	OnlyArchived    bool
+
+	//SortBy orders links by fields: id, title, source, list, created,
+	//priority or rating.
+	SortBy  []string
+	Reverse bool
+	Limit   int
+	Offset  int
+}
+
+func (me *Client) linkFilter(filter Filter) (links.LinkFilter, error) {
+	var conds []links.FilterCondition
+	var query *links.Query
+	if filter.Query != "" {
+		parsed, err := links.ParseQuery(filter.Query)
+		if err != nil {
+			return nil, err
+		}
+
+		query = parsed
+		conds = append(conds, links.MatchQuery(query))
+	}
+
+	if filter.Source != "" {
+		conds = append(conds, links.WithSource(filter.Source))
+	}
+
+	if filter.Title != "" {
+		conds = append(conds, links.WithTitle(filter.Title))
+	}
+
+	if filter.Tag != "" {
+		conds = append(conds, links.WithTag(filter.Tag))
+	}
+
+	if len(filter.IDs) > 0 {
+		conds = append(conds, links.WithIDs(filter.IDs...))
+	}
+
+	if filter.MinPriority > 0 {
+		conds = append(conds, links.MinPriority(filter.MinPriority))
+	}
+
+	if filter.Rated {
+		conds = append(conds, links.IsRated())
+	}
+
+	if filter.List != "" {
+		conds = append(conds, links.FromList(filter.List))
+	} else if len(filter.IDs) == 0 && (query == nil || !query.Uses("list")) {
+		conds = append(conds, links.FromList(me.defaultList))
+	}
+
+	if filter.OnlyArchived {
+		conds = append(conds, links.OnlyArchived())
+	} else if filter.IncludeArchived || (query != nil && query.Uses("archived")) {
+		conds = append(conds, links.IncludeArchived())
+	} else {
+		conds = append(conds, links.NoArchived())
+	}
+
+	if len(filter.SortBy) > 0 {
+		conds = append(conds, links.SortBy(filter.SortBy...))
+	}
+
+	if filter.Reverse {
+		conds = append(conds, links.Reverse())
+	}
+
+	return links.NewFilter(append(conds,
+		links.Skip(filter.Offset),
+		links.Limit(filter.Limit))...), nil
+}
@@ -0,0 +1,164 @@
+package linkman
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/dikeert/linkman/links"
+)
+
+//Rule routes links added without a list. Empty conditions match
+//any link, the rest have to match all for the rule to apply.
+type Rule struct {
+	//Name tells rules apart, "rule N" when empty.
+	Name string
+
+	//Source is source of the link, e.g. youtube.
+	Source string
+	//Host is a glob host of the URL matches, e.g. *.arxiv.org.
+	Host string
+	//Path is a regular expression path of the URL matches.
+	Path string
+	//Title is a regular expression title of the link matches.
+	Title string
+
+	//List is the list the link is added to.
+	List string
+	//Tags are added to tags of the link.
+	Tags []string
+	//Priority is used unless priority is given by WithPriority.
+	Priority int
+	//TitleTemplate makes title of the link, it's text/template
+	//executed with the link and functions given to NewRules.
+	TitleTemplate string
+}
+
+//Rules route links added without a list, the first
+//matching rule applies to the link.
+type Rules struct {
+	rules []*rule
+}
+
+type rule struct {
+	Rule
+	path     *regexp.Regexp
+	title    *regexp.Regexp
+	template *template.Template
+}
+
+//NewRules checks rules and prepares them for matching, funcs
+//are available in title templates, e.g. the ones output formats
+//of linkman commands have.
+func NewRules(rules []Rule, funcs template.FuncMap) (*Rules, error) {
+	me := &Rules{}
+	for i, source := range rules {
+		compiled := &rule{Rule: source}
+		if compiled.Name == "" {
+			compiled.Name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		if err := compiled.compile(funcs); err != nil {
+			return nil, fmt.Errorf("Invalid %s: %s", compiled.Name, err)
+		}
+
+		me.rules = append(me.rules, compiled)
+	}
+
+	return me, nil
+}
+
+func (me *rule) compile(funcs template.FuncMap) error {
+	var err error
+	if _, err = path.Match(me.Host, ""); err != nil {
+		return fmt.Errorf("host %q: %s", me.Host, err)
+	}
+
+	if me.path, err = compileRegexp(me.Path); err != nil {
+		return fmt.Errorf("path %q: %s", me.Path, err)
+	}
+
+	if me.title, err = compileRegexp(me.Title); err != nil {
+		return fmt.Errorf("title %q: %s", me.Title, err)
+	}
+
+	if err := links.CheckPriority(me.Priority); err != nil {
+		return err
+	}
+
+	if me.TitleTemplate != "" {
+		if me.template, err = template.New(me.Name).Funcs(funcs).Parse(me.TitleTemplate); err != nil {
+			return fmt.Errorf("title template: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	return regexp.Compile(pattern)
+}
+
+//match returns the first rule which matches the link, nil
+//when none does.
+func (me *Rules) match(link *Link) *rule {
+	if me == nil {
+		return nil
+	}
+
+	for _, rule := range me.rules {
+		if rule.matches(link) {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+func (me *rule) matches(link *Link) bool {
+	if me.Source != "" && !strings.EqualFold(me.Source, link.Source) {
+		return false
+	}
+
+	if me.Host != "" {
+		if ok, _ := path.Match(strings.ToLower(me.Host), strings.ToLower(link.URL.Hostname())); !ok {
+			return false
+		}
+	}
+
+	if me.path != nil && !me.path.MatchString(link.URL.Path) {
+		return false
+	}
+
+	return me.title == nil || me.title.MatchString(link.Title)
+}
+
+//apply puts the link into the list of the rule, tags it
+//and gives it priority and title the rule has.
+func (me *rule) apply(link *Link) error {
+	if me.List != "" {
+		link.List = me.List
+	}
+
+	link.AddTags(me.Tags...)
+	if link.Priority == 0 {
+		link.Priority = me.Priority
+	}
+
+	if me.template != nil {
+		var title strings.Builder
+		if err := me.template.Execute(&title, link); err != nil {
+			return fmt.Errorf("Unable to make title by %s: %s", me.Name, err)
+		}
+
+		link.Title = title.String()
+	}
+
+	return nil
+}
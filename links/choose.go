@@ -0,0 +1,86 @@
+package links
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+//Weight tells how likely the link is to be chosen by Random
+//relative to other links, now is the time of choosing.
+type Weight func(link *Link, now time.Time) float64
+
+//Uniform gives every link the same chance.
+func Uniform(link *Link, now time.Time) float64 {
+	return 1
+}
+
+//ByAge makes a link the more likely the longer ago it was
+//added, a link added N days ago weighs N+1. Links added
+//before creation time was recorded weigh as new ones.
+func ByAge(link *Link, now time.Time) float64 {
+	if link.Created.IsZero() || link.Created.After(now) {
+		return 1
+	}
+
+	return 1 + now.Sub(link.Created).Hours()/24
+}
+
+//ByPriority makes a link the more likely the higher its
+//priority is, a link with priority N weighs N+1.
+func ByPriority(link *Link, now time.Time) float64 {
+	return float64(1 + link.Priority)
+}
+
+//Oldest returns at most count links in the order they
+//were added, the oldest first.
+func Oldest(found []Link, count int) []Link {
+	ordered := make([]Link, len(found))
+	copy(ordered, found)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return isOlder(&ordered[i], &ordered[j])
+	})
+
+	if count < len(ordered) {
+		ordered = ordered[:count]
+	}
+
+	return ordered
+}
+
+//Random chooses at most count distinct links, each next link
+//is chosen among the remaining ones according to their weights.
+func Random(found []Link, count int, weight Weight, rnd *rand.Rand) []Link {
+	now := time.Now()
+	remaining := make([]Link, len(found))
+	copy(remaining, found)
+
+	weights := make([]float64, len(remaining))
+	for i := range remaining {
+		weights[i] = weight(&remaining[i], now)
+	}
+
+	var chosen []Link
+	for len(chosen) < count && len(remaining) > 0 {
+		total := 0.0
+		for _, w := range weights {
+			total += w
+		}
+
+		index := len(remaining) - 1
+		point := rnd.Float64() * total
+		for i, w := range weights {
+			if point < w {
+				index = i
+				break
+			}
+			point -= w
+		}
+
+		chosen = append(chosen, remaining[index])
+		remaining = append(remaining[:index], remaining[index+1:]...)
+		weights = append(weights[:index], weights[index+1:]...)
+	}
+
+	return chosen
+}
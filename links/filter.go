@@ -1,22 +1,26 @@
 package links
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+)
+
 //FilterCondition is a function that modifies
 //LinkFilter to filter out certain links.
 type FilterCondition func(*linkFilter) *linkFilter
 
 //LinkFilter represents a filtering configuration for links.
 //Such a filtering configuration is a result of combination
-//of several FilterConditions
+//of several FilterConditions. Conditions form a tree which
+//compiles into storm matchers.
 type LinkFilter interface {
-	hasSource() bool
-	hasTitle() bool
-	titleNotEmpty() bool
-
-	getSource() string
-	getTitle() string
-	getList() string
-
-	getArchivedFlag() archivedFlag
+	matchers() ([]q.Matcher, error)
+	refine(query storm.Query) (storm.Query, error)
 }
 
 //NewFilter creates new filter with specified conditions.
@@ -34,40 +38,98 @@ func NewFilter(conditions ...FilterCondition) LinkFilter {
 //This filtering conditions allows only links which
 //Source field matches provided source string.
 func WithSource(source string) FilterCondition {
-	return func(filter *linkFilter) *linkFilter {
-		filter.source = source
-		return filter
-	}
+	return withNode(&fieldNode{Field: "source", Value: source})
 }
 
+//TitleMode tells how title filtering condition matches Title field.
+//IgnoreCase can be combined with other modes, e.g. MatchRegex|IgnoreCase.
+type TitleMode int
+
+const ( // title match modes
+	//MatchSubstring allows titles containing provided string as is.
+	MatchSubstring TitleMode = 0
+	//MatchRegex allows titles matching provided regular expression.
+	MatchRegex TitleMode = 1 << iota
+	//MatchFuzzy allows titles containing all characters of provided
+	//string in the same order, not necessarily adjacent. It always
+	//ignores case.
+	MatchFuzzy
+	//IgnoreCase makes matching case-insensitive.
+	IgnoreCase
+)
+
 //WithTitle creates new filtering condition for Title field.
 //This filtering condition allows only links which
-//Title field contains provided title string.
-func WithTitle(title string) FilterCondition {
-	return func(filter *linkFilter) *linkFilter {
-		filter.title = title
-		return filter
+//Title field matches provided title string according to modes,
+//by default Title should contain title string.
+func WithTitle(title string, modes ...TitleMode) FilterCondition {
+	node := &fieldNode{Field: "title", Value: title}
+	for _, mode := range modes {
+		node.Mode |= mode
 	}
+
+	return withNode(node)
 }
 
 //FromList creates new filtering condition for List field.
 //This filtering condition allows only links which
 //List field matches provided filter string.
+//List "*" allows links from any list. When no list
+//is specified, only links from 'default' list are allowed.
 func FromList(list string) FilterCondition {
-	return func(filter *linkFilter) *linkFilter {
-		filter.list = list
-		return filter
+	if list == "" {
+		return func(filter *linkFilter) *linkFilter {
+			return filter
+		}
 	}
+
+	return withNode(&fieldNode{Field: "list", Value: list})
+}
+
+//WithTag creates new filtering condition for Tags field.
+//This filtering condition allows only links tagged
+//with provided tag.
+func WithTag(tag string) FilterCondition {
+	return withNode(&fieldNode{Field: "tag", Value: tag})
+}
+
+//WithIDs creates new filtering condition for ID field.
+//This filtering condition allows only links with provided IDs.
+//Links are selected by IDs from any list.
+func WithIDs(ids ...int) FilterCondition {
+	alternatives := make(orNode, 0, len(ids))
+	for _, id := range ids {
+		alternatives = append(alternatives,
+			&fieldNode{Field: "id", Value: strconv.Itoa(id)})
+	}
+
+	return withNode(alternatives)
 }
 
 //TitleNotEmpty creates new filtering condition for Title field.
 //This filtering condition allows only links which
 //Title field is not empty.
 func TitleNotEmpty() FilterCondition {
-	return func(filter *linkFilter) *linkFilter {
-		filter.requireTitle = true
-		return filter
-	}
+	return withNode(&fieldNode{Field: "has", Value: "title"})
+}
+
+//MinPriority creates new filtering condition for Priority field.
+//This filtering condition allows only links which priority
+//is at least provided one.
+func MinPriority(priority int) FilterCondition {
+	return withNode(&fieldNode{Field: "priority", Value: ">=" + strconv.Itoa(priority)})
+}
+
+//IsRated creates new filtering condition for Rating field.
+//This filtering condition allows only links that have been rated.
+func IsRated() FilterCondition {
+	return withNode(&fieldNode{Field: "has", Value: "rating"})
+}
+
+//MatchQuery creates new filtering condition from parsed query.
+//This filtering condition allows only links matching the query.
+func MatchQuery(query *Query) FilterCondition {
+	return withNode(query.root)
 }
 
 //IncludeArchived creates new filtering condition for Archived field.
@@ -91,6 +153,94 @@ func NoArchived() FilterCondition {
 	return archivedBuilder(noArchived)
 }
 
+//NoSnoozed creates new filtering condition for SnoozedUntil field.
+//This filtering condition only allows links that are not snoozed
+//at the moment links are looked up.
+func NoSnoozed() FilterCondition {
+	return withNode(&fieldNode{Field: "snoozed", Value: "false"})
+}
+
+//IncludeSnoozed creates new filtering condition for SnoozedUntil
+//field. This filtering condition allows links whether they are
+//snoozed or not, it replaces NoSnoozed of a filter given to FromFilter.
+func IncludeSnoozed() FilterCondition {
+	return withNode(&fieldNode{Field: "snoozed", Value: "*"})
+}
+
+//OnlySnoozed creates new filtering condition for SnoozedUntil field.
+//This filtering condition only allows links that are snoozed
+//at the moment links are looked up.
+func OnlySnoozed() FilterCondition {
+	return withNode(&fieldNode{Field: "snoozed", Value: "true"})
+}
+
+//DueReminders creates new filtering condition for RemindAt field.
+//This filtering condition only allows links which reminders are
+//due at the moment links are looked up.
+func DueReminders() FilterCondition {
+	return withNode(&fieldNode{Field: "due", Value: "true"})
+}
+
+//SortBy creates new ordering condition.
+//Links are ordered by specified field, one of: id, title,
+//source, list, created, priority, rating, snoozed, remind.
+//Several fields can be given, later fields are used
+//when earlier ones are equal.
+func SortBy(fields ...string) FilterCondition {
+	return func(filter *linkFilter) *linkFilter {
+		filter.sortBy = append(filter.sortBy, fields...)
+		return filter
+	}
+}
+
+//Reverse creates new ordering condition that reverses
+//the order of links.
+func Reverse() FilterCondition {
+	return func(filter *linkFilter) *linkFilter {
+		filter.reverse = true
+		return filter
+	}
+}
+
+//Limit creates new condition that allows at most n links.
+//Zero means no limit.
+func Limit(n int) FilterCondition {
+	return func(filter *linkFilter) *linkFilter {
+		filter.limit = n
+		return filter
+	}
+}
+
+//Skip creates new condition that leaves out first n links.
+func Skip(n int) FilterCondition {
+	return func(filter *linkFilter) *linkFilter {
+		filter.skip = n
+		return filter
+	}
+}
+
+var sortFields = map[string]string{
+	"id":       "ID",
+	"title":    "Title",
+	"source":   "Source",
+	"list":     "List",
+	"created":  "Created",
+	"priority": "Priority",
+	"rating":   "Rating",
+	"snoozed":  "SnoozedUntil",
+	"remind":   "RemindAt",
+}
+
+//CheckSortField tells whether links can be ordered by the field,
+//see SortBy for the fields.
+func CheckSortField(name string) error {
+	if _, ok := sortFields[strings.ToLower(name)]; !ok {
+		return fmt.Errorf("Unable to sort by %q", name)
+	}
+
+	return nil
+}
+
 type archivedFlag int
 
 const ( // archived flags
@@ -100,43 +250,79 @@ const ( // archived flags
 )
 
 type linkFilter struct {
-	source       string
-	title        string
-	list         string
-	archived     archivedFlag
-	requireTitle bool
+	nodes    andNode
+	archived archivedFlag
+	sortBy   []string
+	reverse  bool
+	limit    int
+	skip     int
+	//err is the error of a condition, the filter fails with it
+	err error
 }
 
-func (me *linkFilter) hasSource() bool {
-	return me.source != ""
-}
+func (me *linkFilter) matchers() ([]q.Matcher, error) {
+	if me.err != nil {
+		return nil, me.err
+	}
 
-func (me *linkFilter) hasTitle() bool {
-	return me.title != ""
-}
+	nodes := append(andNode{}, me.nodes...)
+	if !nodes.uses("list") && !nodes.uses("id") {
+		nodes = append(nodes, &fieldNode{Field: "list", Value: "default"})
+	}
 
-func (me *linkFilter) titleNotEmpty() bool {
-	return me.requireTitle
-}
+	if me.archived == onlyArchived {
+		nodes = append(nodes, &fieldNode{Field: "archived", Value: "true"})
+	} else if me.archived == noArchived {
+		nodes = append(nodes, &fieldNode{Field: "archived", Value: "false"})
+	}
 
-func (me *linkFilter) getSource() string {
-	return me.source
+	return compileAll(nodes)
 }
 
-func (me *linkFilter) getTitle() string {
-	return me.title
+//filterMatchers compiles the filter, filters not made
+//by NewFilter, e.g. nil, are reported as errors.
+func filterMatchers(filter LinkFilter) ([]q.Matcher, error) {
+	if source, ok := filter.(*linkFilter); !ok || source == nil {
+		return nil, fmt.Errorf("Unsupported filter %T", filter)
+	}
+
+	return filter.matchers()
 }
 
-func (me *linkFilter) getList() string {
-	if me.list == "" {
-		return "default"
+func (me *linkFilter) refine(query storm.Query) (storm.Query, error) {
+	var fields []string
+	for _, name := range me.sortBy {
+		if err := CheckSortField(name); err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, sortFields[strings.ToLower(name)])
+	}
+
+	if len(fields) > 0 {
+		query = query.OrderBy(fields...)
+	}
+
+	if me.reverse {
+		query = query.Reverse()
+	}
+
+	if me.skip > 0 {
+		query = query.Skip(me.skip)
+	}
+
+	if me.limit > 0 {
+		query = query.Limit(me.limit)
 	}
 
-	return me.list
+	return query, nil
 }
 
-func (me *linkFilter) getArchivedFlag() archivedFlag {
-	return me.archived
+func withNode(node filterNode) FilterCondition {
+	return func(filter *linkFilter) *linkFilter {
+		filter.nodes = append(filter.nodes, node)
+		return filter
+	}
 }
 
 func archivedBuilder(flag archivedFlag) FilterCondition {
@@ -149,3 +335,153 @@ func archivedBuilder(flag archivedFlag) FilterCondition {
 		panic("archived filter can be applied only once")
 	}
 }
+
+//filterNode is an element of filter tree.
+type filterNode interface {
+	//compile turns the node into storm matcher.
+	compile() (q.Matcher, error)
+	//uses tells whether the node or its children filter on field.
+	uses(field string) bool
+}
+
+type andNode []filterNode
+
+type orNode []filterNode
+
+type notNode struct {
+	Node filterNode
+}
+
+//fieldNode filters links by one of their fields.
+type fieldNode struct {
+	Field string
+	Value string
+	Mode  TitleMode
+}
+
+func (me andNode) compile() (q.Matcher, error) {
+	matchers, err := compileAll(me)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.And(matchers...), nil
+}
+
+func (me andNode) uses(field string) bool {
+	return anyUses(me, field)
+}
+
+func (me orNode) compile() (q.Matcher, error) {
+	matchers, err := compileAll(me)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.Or(matchers...), nil
+}
+
+func (me orNode) uses(field string) bool {
+	return anyUses(me, field)
+}
+
+func (me *notNode) compile() (q.Matcher, error) {
+	matcher, err := me.Node.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.Not(matcher), nil
+}
+
+func (me *notNode) uses(field string) bool {
+	return me.Node.uses(field)
+}
+
+func (me *fieldNode) compile() (q.Matcher, error) {
+	switch me.Field {
+	case "source":
+		return q.Eq("Source", me.Value), nil
+	case "title":
+		return titleMatcher(me.Value, me.Mode)
+	case "list":
+		if me.Value == "*" {
+			return q.True(), nil
+		}
+		return q.Eq("List", me.Value), nil
+	case "archived":
+		archived, err := strconv.ParseBool(me.Value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid archived value %q", me.Value)
+		}
+		return q.Eq("Archived", archived), nil
+	case "snoozed", "due":
+		if me.Field == "snoozed" && me.Value == "*" {
+			return q.True(), nil
+		}
+
+		value, err := strconv.ParseBool(me.Value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s value %q", me.Field, me.Value)
+		}
+
+		matcher := q.NewFieldMatcher("SnoozedUntil", timeMatcher{now: time.Now()})
+		if me.Field == "due" {
+			matcher = q.NewFieldMatcher("RemindAt", timeMatcher{now: time.Now(), passed: true})
+		}
+
+		if !value {
+			return q.Not(matcher), nil
+		}
+		return matcher, nil
+	case "id":
+		id, err := strconv.Atoi(me.Value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ID %q", me.Value)
+		}
+		return q.Eq("ID", id), nil
+	case "tag":
+		return q.NewFieldMatcher("Tags", tagMatcher(NormalizeTag(me.Value))), nil
+	case "priority":
+		return numberMatcher("Priority", me.Value)
+	case "rating":
+		return numberMatcher("Rating", me.Value)
+	case "has":
+		if me.Value == "title" {
+			return q.Re("Title", "^.+$"), nil
+		} else if me.Value == "rating" {
+			return q.Gt("Rating", 0), nil
+		}
+		return nil, fmt.Errorf("Unknown field in has:%s", me.Value)
+	}
+
+	return nil, fmt.Errorf("Unknown field %q", me.Field)
+}
+
+func (me *fieldNode) uses(field string) bool {
+	return me.Field == field
+}
+
+func compileAll(nodes []filterNode) ([]q.Matcher, error) {
+	matchers := make([]q.Matcher, 0, len(nodes))
+	for _, node := range nodes {
+		matcher, err := node.compile()
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, matcher)
+	}
+
+	return matchers, nil
+}
+
+func anyUses(nodes []filterNode, field string) bool {
+	for _, node := range nodes {
+		if node.uses(field) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,15 +1,20 @@
 package links
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/dikeert/linkman/db"
 
 	"github.com/asdine/storm"
-	"github.com/asdine/storm/q"
 )
 
+//ErrNotFound tells that requested link or saved search doesn't exist.
+var ErrNotFound = errors.New("not found")
+
 //Link holds all the data associated with stored URL in the database.
 type Link struct {
 	ID       int `storm:"id,increment"`
@@ -18,21 +23,58 @@ type Link struct {
 	Title    string
 	List     string `storm:"index"`
 	Archived bool
+	Created  time.Time
+	Tags     []string
+
+	ArchivedAt time.Time
+
+	Description string
+	//Text is readable text of the page, it's stored apart from
+	//the link and is empty in links found, except by Search.
+	Text string `json:"-"`
+
+	//Priority is between 0 and MaxPriority, higher is more important.
+	Priority int
+	//Rating is between MinRating and MaxRating, 0 when not rated.
+	Rating int
+
+	//Note is free text in markdown, e.g. why the link was saved.
+	Note string
+	//Annotations are remarks and highlights in the order they were added.
+	Annotations []Annotation
+
+	//SnoozedUntil hides the link until then, see Snoozed.
+	SnoozedUntil time.Time
+	//RemindAt is when the link is due, zero when there's no reminder.
+	RemindAt time.Time
 }
 
-//Store provides access to storage of Links.
+//Store provides access to storage of Links. Every method
+//waits for the database while other process holds it until
+//ctx is done, changes are rolled back when ctx is done
+//before they are committed.
 type Store interface {
 	NewLink(url *url.URL, source string, title string, list string) *Link
-	SaveLink(link *Link) error
-	LinkExists(url *url.URL) (bool, error)
-	FindLinks(LinkFilter) ([]Link, error)
-	ArchiveByID(id int) error
+	SaveLink(ctx context.Context, link *Link) error
+	LinkExists(ctx context.Context, url *url.URL) (bool, error)
+	FindLinks(ctx context.Context, filter LinkFilter) ([]Link, error)
+	CountLinks(ctx context.Context, filter LinkFilter) (int, error)
+	ArchiveByID(ctx context.Context, id int) error
+	Search(ctx context.Context, query string, filter LinkFilter) ([]SearchResult, error)
+	Reindex(ctx context.Context) error
+	SaveSearch(ctx context.Context, search *SavedSearch) error
+	FindSearch(ctx context.Context, name string) (*SavedSearch, error)
+	SavedSearches(ctx context.Context) ([]SavedSearch, error)
+	DeleteSearch(ctx context.Context, name string) error
+	UpdateLinks(ctx context.Context, filter LinkFilter, update func(*Link)) ([]Link, error)
+	DeleteLinks(ctx context.Context, filter LinkFilter) ([]Link, error)
+	Expire(ctx context.Context, policies map[string]Retention, dryRun bool) (*Expired, error)
 }
 
 //OpenStore creates new Store for database located
 //at provided path.
-func OpenStore(path string) (Store, error) {
-	db, err := db.Open(path)
+func OpenStore(ctx context.Context, path string) (Store, error) {
+	db, err := db.Open(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -60,22 +102,23 @@ func (me *storeImpl) NewLink(url *url.URL, source string, title string, list str
 		Title:    title,
 		List:     list,
 		Archived: false,
+		Created:  time.Now(),
 	}
 
 }
 
-func (me *storeImpl) SaveLink(link *Link) error {
-	db, err := db.Open(me.path)
+func (me *storeImpl) SaveLink(ctx context.Context, link *Link) error {
+	db, err := db.Open(ctx, me.path)
 	if err != nil {
 		return fmt.Errorf("Unable to open database: %s", err)
 	}
 
 	defer db.Close()
-	return save(db, link)
+	return save(ctx, db, link)
 }
 
-func (me *storeImpl) LinkExists(url *url.URL) (bool, error) {
-	db, err := db.Open(me.path)
+func (me *storeImpl) LinkExists(ctx context.Context, url *url.URL) (bool, error) {
+	db, err := db.Open(ctx, me.path)
 	if err != nil {
 		return false, err
 	}
@@ -85,18 +128,30 @@ func (me *storeImpl) LinkExists(url *url.URL) (bool, error) {
 	return len(links) > 0, err
 }
 
-func (me *storeImpl) FindLinks(filter LinkFilter) ([]Link, error) {
-	db, err := db.Open(me.path)
+func (me *storeImpl) FindLinks(ctx context.Context, filter LinkFilter) ([]Link, error) {
+	db, err := db.Open(ctx, me.path)
 	if err != nil {
 		return nil, err
 	}
 
+	defer db.Close()
 	return findLinks(db, filter)
 }
 
+//CountLinks counts links allowed by the filter.
+func (me *storeImpl) CountLinks(ctx context.Context, filter LinkFilter) (int, error) {
+	db, err := db.Open(ctx, me.path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer db.Close()
+	return countLinks(db, filter)
+}
+
 //ArchiveByID archived the links with specified id.
-func (me *storeImpl) ArchiveByID(id int) error {
-	db, err := db.Open(me.path)
+func (me *storeImpl) ArchiveByID(ctx context.Context, id int) error {
+	db, err := db.Open(ctx, me.path)
 	if err != nil {
 		return err
 	}
@@ -105,45 +160,132 @@ func (me *storeImpl) ArchiveByID(id int) error {
 	return archiveByID(db, id)
 }
 
-func initDatabase(db *storm.DB) error {
-	err := db.Init(&Link{})
+//Search finds links matching full-text query among links
+//allowed by the filter, best matches first.
+func (me *storeImpl) Search(ctx context.Context, query string, filter LinkFilter) ([]SearchResult, error) {
+	db, err := db.Open(ctx, me.path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+	return search(ctx, db, query, filter)
+}
+
+//Reindex rebuilds full-text index from scratch.
+func (me *storeImpl) Reindex(ctx context.Context) error {
+	db, err := db.Open(ctx, me.path)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	defer db.Close()
+	return reindex(ctx, db)
 }
 
-func findLinks(db *storm.DB, filter LinkFilter) ([]Link, error) {
-	var matchers []q.Matcher
-	var result []Link
+//SaveSearch stores the search under its name,
+//replacing existing search with the same name.
+func (me *storeImpl) SaveSearch(ctx context.Context, search *SavedSearch) error {
+	db, err := db.Open(ctx, me.path)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+	return saveSearch(db, search)
+}
+
+//FindSearch looks up saved search by its name.
+func (me *storeImpl) FindSearch(ctx context.Context, name string) (*SavedSearch, error) {
+	db, err := db.Open(ctx, me.path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+	return findSearch(db, name)
+}
+
+//SavedSearches returns all saved searches ordered by name.
+func (me *storeImpl) SavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	db, err := db.Open(ctx, me.path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+	return allSearches(db)
+}
 
-	if filter.hasSource() {
-		matchers = append(matchers,
-			q.Eq("Source", filter.getSource()))
+//DeleteSearch removes saved search with specified name.
+func (me *storeImpl) DeleteSearch(ctx context.Context, name string) error {
+	db, err := db.Open(ctx, me.path)
+	if err != nil {
+		return err
 	}
 
-	if filter.hasTitle() {
-		matchers = append(matchers,
-			q.Re("Title", fmt.Sprintf("^.+%s.+$", filter.getTitle())))
+	defer db.Close()
+	return deleteSearch(db, name)
+}
+
+//UpdateLinks applies update to every link allowed by the filter
+//in a single transaction and returns updated links.
+func (me *storeImpl) UpdateLinks(ctx context.Context, filter LinkFilter, update func(*Link)) ([]Link, error) {
+	db, err := db.Open(ctx, me.path)
+	if err != nil {
+		return nil, err
 	}
 
-	if filter.titleNotEmpty() {
-		matchers = append(matchers,
-			q.Re("Title", "^.+$"))
+	defer db.Close()
+	return updateLinks(ctx, db, filter, update)
+}
+
+//DeleteLinks removes every link allowed by the filter
+//in a single transaction and returns removed links.
+func (me *storeImpl) DeleteLinks(ctx context.Context, filter LinkFilter) ([]Link, error) {
+	db, err := db.Open(ctx, me.path)
+	if err != nil {
+		return nil, err
 	}
 
-	if list := filter.getList(); list != "*" {
-		matchers = append(matchers, q.Eq("List", list))
+	defer db.Close()
+	return deleteLinks(ctx, db, filter)
+}
+
+//Expire archives and deletes links as retention policies
+//of their lists tell in a single transaction.
+func (me *storeImpl) Expire(ctx context.Context, policies map[string]Retention, dryRun bool) (*Expired, error) {
+	db, err := db.Open(ctx, me.path)
+	if err != nil {
+		return nil, err
 	}
 
-	if filter.getArchivedFlag() == onlyArchived {
-		matchers = append(matchers, q.Eq("Archived", true))
-	} else if filter.getArchivedFlag() == noArchived {
-		matchers = append(matchers, q.Eq("Archived", false))
+	defer db.Close()
+	return expire(ctx, db, policies, time.Now(), dryRun)
+}
+
+func initDatabase(db *storm.DB) error {
+	err := db.Init(&Link{})
+	if err != nil {
+		return err
 	}
 
-	if err := db.Select(matchers...).Find(&result); err == nil {
+	return nil
+}
+
+func findLinks(db storm.Node, filter LinkFilter) ([]Link, error) {
+	var result []Link
+	matchers, err := filterMatchers(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := filter.refine(db.Select(matchers...))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := query.Find(&result); err == nil {
 		return result, nil
 	} else if err == storm.ErrNotFound {
 		return result, nil
@@ -152,6 +294,25 @@ func findLinks(db *storm.DB, filter LinkFilter) ([]Link, error) {
 	}
 }
 
+func countLinks(db storm.Node, filter LinkFilter) (int, error) {
+	matchers, err := filterMatchers(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	query, err := filter.refine(db.Select(matchers...))
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := query.Count(&Link{})
+	if err == storm.ErrNotFound {
+		return 0, nil
+	}
+
+	return count, err
+}
+
 func findLinksByURL(db *storm.DB, url *url.URL) ([]Link, error) {
 	var links []Link
 	err := db.Find("URL", url, &links)
@@ -164,7 +325,7 @@ func findLinksByURL(db *storm.DB, url *url.URL) ([]Link, error) {
 }
 
 func archiveByID(db *storm.DB, id int) error {
-	err := db.UpdateField(&Link{ID: id}, "Archived", true)
+	err := db.Update(&Link{ID: id, Archived: true, ArchivedAt: time.Now()})
 	if err != nil {
 		return err
 	}
@@ -172,11 +333,95 @@ func archiveByID(db *storm.DB, id int) error {
 	return nil
 }
 
-func save(db *storm.DB, link *Link) error {
-	err := db.Save(link)
+func updateLinks(ctx context.Context, db *storm.DB, filter LinkFilter, update func(*Link)) ([]Link, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+	found, err := findLinks(tx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range found {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		update(&found[i])
+		if err := tx.Save(&found[i]); err != nil {
+			return nil, fmt.Errorf("Unable to save link: %s", err)
+		}
+
+		if err := saveText(tx, &found[i]); err != nil {
+			return nil, fmt.Errorf("Unable to save page text: %s", err)
+		}
+
+		if err := indexLink(tx, &found[i]); err != nil {
+			return nil, fmt.Errorf("Unable to index link: %s", err)
+		}
+	}
+
+	return found, tx.Commit()
+}
+
+func deleteLinks(ctx context.Context, db *storm.DB, filter LinkFilter) ([]Link, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+	found, err := findLinks(tx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range found {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := tx.DeleteStruct(&found[i]); err != nil {
+			return nil, fmt.Errorf("Unable to delete link: %s", err)
+		}
+
+		if err := unindexLink(tx, found[i].ID); err != nil {
+			return nil, fmt.Errorf("Unable to update index: %s", err)
+		}
+
+		if err := deleteText(tx, found[i].ID); err != nil {
+			return nil, fmt.Errorf("Unable to delete page text: %s", err)
+		}
+	}
+
+	return found, tx.Commit()
+}
+
+func save(ctx context.Context, db *storm.DB, link *Link) error {
+	tx, err := db.Begin(true)
 	if err != nil {
 		return fmt.Errorf("Unable to save link: %s", err)
 	}
 
-	return nil
+	defer tx.Rollback()
+	if err := tx.Save(link); err != nil {
+		return fmt.Errorf("Unable to save link: %s", err)
+	}
+
+	if err := saveText(tx, link); err != nil {
+		return fmt.Errorf("Unable to save page text: %s", err)
+	}
+
+	if err := indexLink(tx, link); err != nil {
+		return fmt.Errorf("Unable to index link: %s", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("Unable to save link: %w", err)
+	}
+
+	return tx.Commit()
 }
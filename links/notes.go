@@ -0,0 +1,33 @@
+package links
+
+import (
+	"strings"
+	"time"
+)
+
+//Annotation is a timestamped remark or highlight of a link.
+type Annotation struct {
+	Text    string
+	Created time.Time
+}
+
+//Annotate adds annotation with the text to the link,
+//surrounding spaces are trimmed and empty text is ignored.
+func (me *Link) Annotate(text string) {
+	if text = strings.TrimSpace(text); text != "" {
+		me.Annotations = append(me.Annotations, Annotation{
+			Text:    text,
+			Created: time.Now(),
+		})
+	}
+}
+
+//annotationsText joins texts of all annotations of the link.
+func (me *Link) annotationsText() string {
+	texts := make([]string, 0, len(me.Annotations))
+	for _, annotation := range me.Annotations {
+		texts = append(texts, annotation.Text)
+	}
+
+	return strings.Join(texts, "\n")
+}
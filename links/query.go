@@ -0,0 +1,462 @@
+package links
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+//Query is a parsed filter expression.
+//
+//Query consists of terms, terms separated by spaces are combined
+//using 'and' operation, terms separated by '|' (or 'OR') are combined
+//using 'or' operation. A term prefixed with '-' (or 'NOT') is negated,
+//parentheses group terms together.
+//
+//A term is either field:value or a bare word. Bare words match
+//titles containing them, ignoring case. Supported fields:
+//
+// - list:name - links from the list, list:* matches any list
+// - source:name - links from the source
+// - title:text - links which title contains text, ignoring case
+// - title:/regex/ - links which title matches regex, ignoring case,
+//   the regex ends at the next '/', write '\/' for '/' inside it
+// - archived:true|false - links with that archived status
+// - snoozed:true|false - links snoozed at the moment or not
+// - due:true|false - links which reminders are due or not
+// - id:N - link with that ID
+// - tag:name - links tagged with name
+// - priority:N - links with priority N, priority:>=N for at least N,
+//   >, < and <= work too
+// - rating:N - links rated N, comparisons work as for priority
+// - has:title - links which title is not empty
+// - has:rating - links that have been rated
+//
+//Values can be quoted, "like this", and alternatives for a single
+//field can be grouped: list:(reading|watch).
+//
+//Example:
+//
+// list:(reading|watch) -source:youtube title:rust archived:false
+type Query struct {
+	text string
+	root filterNode
+}
+
+//ParseQuery parses query text into Query, see Query for syntax.
+func ParseQuery(text string) (*Query, error) {
+	tokens, err := lexQuery(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid query: %s", err)
+	}
+
+	parser := &queryParser{tokens: tokens}
+	root, err := parser.parse()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid query: %s", err)
+	}
+
+	if _, err := root.compile(); err != nil {
+		return nil, fmt.Errorf("Invalid query: %s", err)
+	}
+
+	return &Query{text: text, root: root}, nil
+}
+
+//Uses tells whether query filters on specified field,
+//e.g. whether it contains "list:" terms.
+func (me *Query) Uses(field string) bool {
+	return me.root.uses(field)
+}
+
+func (me *Query) String() string {
+	return me.text
+}
+
+type tokenKind int
+
+const ( // query token kinds
+	wordToken tokenKind = iota + 1
+	stringToken
+	colonToken
+	orToken
+	notToken
+	openToken
+	closeToken
+)
+
+type queryToken struct {
+	kind  tokenKind
+	value string
+}
+
+func lexQuery(text string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(text)
+	//titleGroup tells whether title:(...) alternatives are lexed
+	titleGroup := false
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && startsRegex(tokens, titleGroup):
+			value, n, err := lexRegex(runes[i:])
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, queryToken{kind: wordToken, value: value})
+			i += n
+		case r == '(':
+			titleGroup = followsTitle(tokens)
+			tokens = append(tokens, queryToken{kind: openToken})
+			i++
+		case r == ')':
+			titleGroup = false
+			tokens = append(tokens, queryToken{kind: closeToken})
+			i++
+		case r == '|':
+			tokens = append(tokens, queryToken{kind: orToken})
+			i++
+		case r == ':':
+			tokens = append(tokens, queryToken{kind: colonToken})
+			i++
+		case (r == '-' || r == '!') && startsTerm(tokens):
+			tokens = append(tokens, queryToken{kind: notToken})
+			i++
+		case r == '"':
+			value, n, err := lexString(runes[i:])
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, queryToken{kind: stringToken, value: value})
+			i += n
+		default:
+			start := i
+			for i < len(runes) && !isQuerySpecial(runes[i]) {
+				i++
+			}
+
+			tokens = append(tokens, keywordOrWord(string(runes[start:i])))
+		}
+	}
+
+	return tokens, nil
+}
+
+//startsTerm tells whether next token begins a new term,
+//which is where '-' means negation rather than part of a word.
+func startsTerm(tokens []queryToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+
+	last := tokens[len(tokens)-1].kind
+	return last != colonToken
+}
+
+//followsTitle tells whether the last tokens are title:.
+func followsTitle(tokens []queryToken) bool {
+	n := len(tokens)
+	return n > 1 && tokens[n-1].kind == colonToken &&
+		tokens[n-2].kind == wordToken && strings.EqualFold(tokens[n-2].value, "title")
+}
+
+//startsRegex tells whether '/' begins a title regex, which is
+//lexed as a single value up to the closing '/'.
+func startsRegex(tokens []queryToken, titleGroup bool) bool {
+	if followsTitle(tokens) {
+		return true
+	}
+
+	if !titleGroup || len(tokens) == 0 {
+		return false
+	}
+
+	last := tokens[len(tokens)-1].kind
+	return last == openToken || last == orToken
+}
+
+func isQuerySpecial(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`()|:"`, r)
+}
+
+func keywordOrWord(word string) queryToken {
+	switch word {
+	case "OR":
+		return queryToken{kind: orToken}
+	case "NOT":
+		return queryToken{kind: notToken}
+	}
+
+	return queryToken{kind: wordToken, value: word}
+}
+
+func lexString(runes []rune) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteRune(runes[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+//lexRegex reads /regex/ keeping its slashes, '\/' stands for '/'
+//and other escapes are left to the regex.
+func lexRegex(runes []rune) (string, int, error) {
+	var b strings.Builder
+	b.WriteRune('/')
+	for i := 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			b.WriteRune('\\')
+			if i+1 < len(runes) {
+				i++
+				b.WriteRune(runes[i])
+			}
+		case '/':
+			b.WriteRune('/')
+			return b.String(), i + 1, nil
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+
+	return "", 0, fmt.Errorf("unterminated regex")
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (me *queryParser) parse() (filterNode, error) {
+	node, err := me.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if me.pos < len(me.tokens) {
+		return nil, fmt.Errorf("unexpected %s", me.describe(me.tokens[me.pos]))
+	}
+
+	return node, nil
+}
+
+func (me *queryParser) peek() (queryToken, bool) {
+	if me.pos < len(me.tokens) {
+		return me.tokens[me.pos], true
+	}
+
+	return queryToken{}, false
+}
+
+func (me *queryParser) accept(kind tokenKind) bool {
+	if token, ok := me.peek(); ok && token.kind == kind {
+		me.pos++
+		return true
+	}
+
+	return false
+}
+
+func (me *queryParser) parseOr() (filterNode, error) {
+	var alternatives orNode
+	for {
+		node, err := me.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		alternatives = append(alternatives, node)
+		if !me.accept(orToken) {
+			break
+		}
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+
+	return alternatives, nil
+}
+
+func (me *queryParser) parseAnd() (filterNode, error) {
+	var terms andNode
+	for {
+		token, ok := me.peek()
+		if !ok || token.kind == orToken || token.kind == closeToken {
+			break
+		}
+
+		if token.kind == wordToken && token.value == "AND" {
+			me.pos++
+			continue
+		}
+
+		node, err := me.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		terms = append(terms, node)
+	}
+
+	if len(terms) == 0 {
+		return nil, me.unexpected()
+	}
+
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+
+	return terms, nil
+}
+
+func (me *queryParser) parseUnary() (filterNode, error) {
+	if me.accept(notToken) {
+		node, err := me.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &notNode{Node: node}, nil
+	}
+
+	if me.accept(openToken) {
+		node, err := me.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !me.accept(closeToken) {
+			return nil, me.expected("')'")
+		}
+
+		return node, nil
+	}
+
+	return me.parseTerm()
+}
+
+func (me *queryParser) parseTerm() (filterNode, error) {
+	token, ok := me.peek()
+	if !ok || (token.kind != wordToken && token.kind != stringToken) {
+		return nil, me.unexpected()
+	}
+
+	me.pos++
+	if token.kind == wordToken && me.accept(colonToken) {
+		return me.parseField(strings.ToLower(token.value))
+	}
+
+	return titleNode(token.value), nil
+}
+
+func (me *queryParser) parseField(field string) (filterNode, error) {
+	if !me.accept(openToken) {
+		value, err := me.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		return newFieldNode(field, value), nil
+	}
+
+	var alternatives orNode
+	for {
+		value, err := me.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		alternatives = append(alternatives, newFieldNode(field, value))
+		if !me.accept(orToken) {
+			break
+		}
+	}
+
+	if !me.accept(closeToken) {
+		return nil, me.expected("')'")
+	}
+
+	return alternatives, nil
+}
+
+func (me *queryParser) parseValue() (string, error) {
+	token, ok := me.peek()
+	if !ok || (token.kind != wordToken && token.kind != stringToken) {
+		return "", me.expected("value")
+	}
+
+	me.pos++
+	return token.value, nil
+}
+
+func (me *queryParser) unexpected() error {
+	if token, ok := me.peek(); ok {
+		return fmt.Errorf("unexpected %s", me.describe(token))
+	}
+
+	return fmt.Errorf("unexpected end of query")
+}
+
+func (me *queryParser) expected(what string) error {
+	if token, ok := me.peek(); ok {
+		return fmt.Errorf("expected %s, got %s", what, me.describe(token))
+	}
+
+	return fmt.Errorf("expected %s at the end of query", what)
+}
+
+func (me *queryParser) describe(token queryToken) string {
+	switch token.kind {
+	case wordToken, stringToken:
+		return fmt.Sprintf("%q", token.value)
+	case colonToken:
+		return "':'"
+	case orToken:
+		return "'|'"
+	case notToken:
+		return "'-'"
+	case openToken:
+		return "'('"
+	case closeToken:
+		return "')'"
+	}
+
+	return "token"
+}
+
+func newFieldNode(field string, value string) filterNode {
+	if field == "title" {
+		return titleNode(value)
+	}
+
+	return &fieldNode{Field: field, Value: value}
+}
+
+func titleNode(value string) filterNode {
+	if len(value) > 1 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		return &fieldNode{
+			Field: "title",
+			Value: value[1 : len(value)-1],
+			Mode:  MatchRegex | IgnoreCase,
+		}
+	}
+
+	return &fieldNode{Field: "title", Value: value, Mode: IgnoreCase}
+}
@@ -0,0 +1,76 @@
+package links
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/asdine/storm/q"
+)
+
+const ( // priority and rating bounds
+	//MaxPriority is the highest priority, links
+	//have priority 0 unless it's set.
+	MaxPriority = 3
+	//MinRating and MaxRating bound rating of a link,
+	//rating 0 means the link is not rated.
+	MinRating = 1
+	MaxRating = 5
+)
+
+//CheckPriority tells whether priority is between 0 and MaxPriority.
+func CheckPriority(priority int) error {
+	if priority < 0 || priority > MaxPriority {
+		return fmt.Errorf("priority should be between 0 and %d, got %d", MaxPriority, priority)
+	}
+
+	return nil
+}
+
+//CheckRating tells whether rating is between MinRating and MaxRating,
+//0 is allowed too, it clears the rating.
+func CheckRating(rating int) error {
+	if rating != 0 && (rating < MinRating || rating > MaxRating) {
+		return fmt.Errorf("rating should be between %d and %d, got %d", MinRating, MaxRating, rating)
+	}
+
+	return nil
+}
+
+//Rated tells whether link has been rated.
+func (me *Link) Rated() bool {
+	return me.Rating > 0
+}
+
+//comparisons are prefixes of numeric values in queries,
+//longer prefixes go first.
+var comparisons = []struct {
+	prefix  string
+	matcher func(field string, value interface{}) q.Matcher
+}{
+	{">=", q.Gte},
+	{"<=", q.Lte},
+	{">", q.Gt},
+	{"<", q.Lt},
+	{"=", q.Eq},
+	{"", q.Eq},
+}
+
+//numberMatcher matches numeric field against value like
+//2, >=2 or <3.
+func numberMatcher(field string, value string) (q.Matcher, error) {
+	for _, comparison := range comparisons {
+		if !strings.HasPrefix(value, comparison.prefix) {
+			continue
+		}
+
+		number, err := strconv.Atoi(strings.TrimPrefix(value, comparison.prefix))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s %q", strings.ToLower(field), value)
+		}
+
+		return comparison.matcher(field, number), nil
+	}
+
+	return nil, fmt.Errorf("Invalid %s %q", strings.ToLower(field), value)
+}
@@ -0,0 +1,146 @@
+package links
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asdine/storm"
+)
+
+//Retention limits how long links of a list are kept,
+//zero turns a rule off. Snoozed links are left alone.
+//Links added before linkman recorded when aren't archived
+//for their age, links archived before that are stamped as
+//archived now, so they are deleted DeleteAfter later.
+type Retention struct {
+	//ArchiveAfter archives unread links added longer ago than that.
+	ArchiveAfter time.Duration
+	//DeleteAfter deletes links archived longer ago than that.
+	DeleteAfter time.Duration
+	//Keep archives the oldest unread links beyond that many.
+	Keep int
+}
+
+//Expired holds links retention policies archived and deleted.
+type Expired struct {
+	Archived []Link
+	Deleted  []Link
+}
+
+//expire applies retention policies by list, compared regardless
+//of case, in a single transaction. Links archived by the policies
+//are deleted by the next run at the earliest. When dryRun is set
+//links which would expire are returned from a read-only transaction
+//and links archived at unknown time aren't stamped.
+func expire(ctx context.Context,
+	db *storm.DB,
+	policies map[string]Retention,
+	now time.Time,
+	dryRun bool) (*Expired, error) {
+	byList := map[string]Retention{}
+	for list, policy := range policies {
+		byList[strings.ToLower(list)] = policy
+	}
+
+	tx, err := db.Begin(!dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+	found, err := findLinks(tx, NewFilter(FromList("*"), IncludeArchived()))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Expired{}
+	var stamped []*Link
+	unread := map[string][]*Link{}
+	for i := range found {
+		link := &found[i]
+		list := strings.ToLower(link.List)
+		policy, ok := byList[list]
+		if !ok {
+			continue
+		}
+
+		if link.Archived && link.ArchivedAt.IsZero() {
+			link.ArchivedAt = now
+			stamped = append(stamped, link)
+		} else if link.Archived {
+			if policy.DeleteAfter > 0 && now.Sub(link.ArchivedAt) > policy.DeleteAfter {
+				result.Deleted = append(result.Deleted, *link)
+			}
+		} else if !link.Snoozed(now) {
+			unread[list] = append(unread[list], link)
+		}
+	}
+
+	for list, queue := range unread {
+		policy := byList[list]
+		sort.Slice(queue, func(i, j int) bool {
+			return isOlder(queue[i], queue[j])
+		})
+
+		for i, link := range queue {
+			old := policy.ArchiveAfter > 0 && !link.Created.IsZero() &&
+				now.Sub(link.Created) > policy.ArchiveAfter
+			if old || (policy.Keep > 0 && len(queue)-i > policy.Keep) {
+				link.Archived = true
+				link.ArchivedAt = now
+				result.Archived = append(result.Archived, *link)
+			}
+		}
+	}
+
+	sort.Slice(result.Archived, func(i, j int) bool {
+		return result.Archived[i].ID < result.Archived[j].ID
+	})
+
+	if dryRun {
+		return result, nil
+	}
+
+	for _, link := range stamped {
+		if err := tx.Save(link); err != nil {
+			return nil, fmt.Errorf("Unable to save link: %s", err)
+		}
+	}
+
+	for i := range result.Archived {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Save(&result.Archived[i]); err != nil {
+			return nil, fmt.Errorf("Unable to save link: %s", err)
+		}
+
+		if err := indexLink(tx, &result.Archived[i]); err != nil {
+			return nil, fmt.Errorf("Unable to index link: %s", err)
+		}
+	}
+
+	for i := range result.Deleted {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := tx.DeleteStruct(&result.Deleted[i]); err != nil {
+			return nil, fmt.Errorf("Unable to delete link: %s", err)
+		}
+
+		if err := unindexLink(tx, result.Deleted[i].ID); err != nil {
+			return nil, fmt.Errorf("Unable to update index: %s", err)
+		}
+
+		if err := deleteText(tx, result.Deleted[i].ID); err != nil {
+			return nil, fmt.Errorf("Unable to delete page text: %s", err)
+		}
+	}
+
+	return result, tx.Commit()
+}
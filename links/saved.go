@@ -0,0 +1,292 @@
+package links
+
+import (
+	"fmt"
+
+	"github.com/asdine/storm"
+)
+
+//SavedSearch is a named filter stored in the database.
+//Saved searches behave like virtual lists: any command
+//that accepts a filter can be pointed at them.
+type SavedSearch struct {
+	Name string
+	//Definition is a human readable form of the filter,
+	//e.g. command line flags it was created from.
+	Definition string
+	Filter     LinkFilter
+}
+
+//FromFilter creates new filtering condition that applies all
+//conditions of another filter. Conditions already applied to
+//the filter take precedence over list, archived and snoozed
+//status, limit and offset of another filter, its ordering is
+//used as a fallback. Filters not made by NewFilter, e.g. nil,
+//make finding links fail.
+func FromFilter(other LinkFilter) FilterCondition {
+	return func(filter *linkFilter) *linkFilter {
+		source, ok := other.(*linkFilter)
+		if !ok || source == nil {
+			filter.err = fmt.Errorf("Unsupported filter %T", other)
+			return filter
+		}
+
+		if source.err != nil && filter.err == nil {
+			filter.err = source.err
+		}
+
+		applied := filter.nodes
+		for _, node := range source.nodes {
+			if !overrides(applied, node) {
+				filter.nodes = append(filter.nodes, node)
+			}
+		}
+
+		filter.sortBy = append(filter.sortBy, source.sortBy...)
+		filter.reverse = filter.reverse || source.reverse
+		if filter.archived == 0 {
+			filter.archived = source.archived
+		}
+
+		if filter.limit == 0 {
+			filter.limit = source.limit
+		}
+
+		if filter.skip == 0 {
+			filter.skip = source.skip
+		}
+
+		return filter
+	}
+}
+
+//overridable are fields which conditions applied to a filter
+//replace the same conditions of another filter given to FromFilter.
+var overridable = []string{"list", "snoozed"}
+
+//overrides tells whether nodes replace the node of another filter.
+func overrides(nodes andNode, node filterNode) bool {
+	field, ok := node.(*fieldNode)
+	if !ok {
+		return false
+	}
+
+	for _, name := range overridable {
+		if field.Field == name && nodes.uses(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//savedSearch is how SavedSearch is stored in the database.
+type savedSearch struct {
+	Name       string `storm:"id"`
+	Definition string
+	Filter     filterRecord
+}
+
+type filterRecord struct {
+	Nodes    []nodeRecord `json:",omitempty"`
+	Archived archivedFlag `json:",omitempty"`
+	SortBy   []string     `json:",omitempty"`
+	Reverse  bool         `json:",omitempty"`
+	Limit    int          `json:",omitempty"`
+	Skip     int          `json:",omitempty"`
+}
+
+type nodeRecord struct {
+	Op       string       `json:",omitempty"`
+	Field    string       `json:",omitempty"`
+	Value    string       `json:",omitempty"`
+	Mode     TitleMode    `json:",omitempty"`
+	Children []nodeRecord `json:",omitempty"`
+}
+
+const ( // node record operations
+	andOp = "and"
+	orOp  = "or"
+	notOp = "not"
+)
+
+func encodeFilter(filter LinkFilter) (filterRecord, error) {
+	source, ok := filter.(*linkFilter)
+	if !ok || source == nil {
+		return filterRecord{}, fmt.Errorf("Unsupported filter %T", filter)
+	}
+
+	if source.err != nil {
+		return filterRecord{}, source.err
+	}
+
+	nodes, err := encodeNodes(source.nodes)
+	if err != nil {
+		return filterRecord{}, err
+	}
+
+	return filterRecord{
+		Nodes:    nodes,
+		Archived: source.archived,
+		SortBy:   source.sortBy,
+		Reverse:  source.reverse,
+		Limit:    source.limit,
+		Skip:     source.skip,
+	}, nil
+}
+
+func decodeFilter(record filterRecord) (LinkFilter, error) {
+	nodes, err := decodeNodes(record.Nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &linkFilter{
+		nodes:    nodes,
+		archived: record.Archived,
+		sortBy:   record.SortBy,
+		reverse:  record.Reverse,
+		limit:    record.Limit,
+		skip:     record.Skip,
+	}, nil
+}
+
+func encodeNodes(nodes []filterNode) ([]nodeRecord, error) {
+	records := make([]nodeRecord, 0, len(nodes))
+	for _, node := range nodes {
+		record, err := encodeNode(node)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func encodeNode(node filterNode) (nodeRecord, error) {
+	var op string
+	var children []filterNode
+	switch n := node.(type) {
+	case andNode:
+		op, children = andOp, n
+	case orNode:
+		op, children = orOp, n
+	case *notNode:
+		op, children = notOp, []filterNode{n.Node}
+	case *fieldNode:
+		return nodeRecord{Field: n.Field, Value: n.Value, Mode: n.Mode}, nil
+	default:
+		return nodeRecord{}, fmt.Errorf("Unable to save filter node %T", node)
+	}
+
+	records, err := encodeNodes(children)
+	if err != nil {
+		return nodeRecord{}, err
+	}
+
+	return nodeRecord{Op: op, Children: records}, nil
+}
+
+func decodeNodes(records []nodeRecord) ([]filterNode, error) {
+	nodes := make([]filterNode, 0, len(records))
+	for _, record := range records {
+		node, err := decodeNode(record)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+func decodeNode(record nodeRecord) (filterNode, error) {
+	children, err := decodeNodes(record.Children)
+	if err != nil {
+		return nil, err
+	}
+
+	switch record.Op {
+	case andOp:
+		return andNode(children), nil
+	case orOp:
+		return orNode(children), nil
+	case notOp:
+		if len(children) != 1 {
+			return nil, fmt.Errorf("Malformed filter: 'not' expects one operand")
+		}
+		return &notNode{Node: children[0]}, nil
+	case "":
+		return &fieldNode{Field: record.Field, Value: record.Value, Mode: record.Mode}, nil
+	}
+
+	return nil, fmt.Errorf("Malformed filter: unknown operation %q", record.Op)
+}
+
+func saveSearch(db *storm.DB, search *SavedSearch) error {
+	filter, err := encodeFilter(search.Filter)
+	if err != nil {
+		return err
+	}
+
+	return db.Save(&savedSearch{
+		Name:       search.Name,
+		Definition: search.Definition,
+		Filter:     filter,
+	})
+}
+
+func findSearch(db *storm.DB, name string) (*SavedSearch, error) {
+	var record savedSearch
+	if err := db.One("Name", name, &record); err == storm.ErrNotFound {
+		return nil, fmt.Errorf("Saved search %q %w", name, ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toSavedSearch(record)
+}
+
+func allSearches(db *storm.DB) ([]SavedSearch, error) {
+	var records []savedSearch
+	if err := db.All(&records); err != nil {
+		return nil, err
+	}
+
+	searches := make([]SavedSearch, 0, len(records))
+	for _, record := range records {
+		search, err := toSavedSearch(record)
+		if err != nil {
+			return nil, err
+		}
+
+		searches = append(searches, *search)
+	}
+
+	return searches, nil
+}
+
+func deleteSearch(db *storm.DB, name string) error {
+	err := db.DeleteStruct(&savedSearch{Name: name})
+	if err == storm.ErrNotFound {
+		return fmt.Errorf("Saved search %q %w", name, ErrNotFound)
+	}
+
+	return err
+}
+
+func toSavedSearch(record savedSearch) (*SavedSearch, error) {
+	filter, err := decodeFilter(record.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load saved search %q: %s", record.Name, err)
+	}
+
+	return &SavedSearch{
+		Name:       record.Name,
+		Definition: record.Definition,
+		Filter:     filter,
+	}, nil
+}
@@ -0,0 +1,386 @@
+package links
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
+)
+
+const ( // full-text index buckets
+	termsBucket = "search_terms"
+	docsBucket  = "search_docs"
+)
+
+const ( // field weights used when indexing a link
+	titleWeight       = 3
+	descriptionWeight = 2
+	noteWeight        = 2
+	textWeight        = 1
+)
+
+const snippetWidth = 160
+
+//SearchResult is a link found by full-text search together
+//with its relevance score and a snippet of matching text.
+type SearchResult struct {
+	Link    Link
+	Score   float64
+	Snippet string
+	//Matches holds [start, end) byte offsets of query terms in Snippet.
+	Matches [][2]int
+}
+
+//indexedDoc is what the index remembers about a link,
+//so the postings can be removed when the link changes.
+type indexedDoc struct {
+	Terms map[string]int
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true,
+	"at": true, "be": true, "by": true, "for": true, "from": true,
+	"in": true, "is": true, "it": true, "of": true, "on": true,
+	"or": true, "that": true, "the": true, "this": true, "to": true,
+	"was": true, "with": true,
+}
+
+//tokenize splits text into lowercased terms suitable for
+//the full-text index. Stop words are dropped.
+func tokenize(text string) []string {
+	var terms []string
+	words := strings.FieldsFunc(strings.ToLower(text), isSeparator)
+	for _, word := range words {
+		if len(word) > 1 && !stopWords[word] {
+			terms = append(terms, word)
+		}
+	}
+
+	return terms
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func weightTerms(link *Link) map[string]int {
+	weights := map[string]int{}
+	add := func(text string, weight int) {
+		for _, term := range tokenize(text) {
+			weights[term] += weight
+		}
+	}
+
+	add(link.Title, titleWeight)
+	add(link.Description, descriptionWeight)
+	add(link.Note, noteWeight)
+	add(link.annotationsText(), noteWeight)
+	add(link.Text, textWeight)
+	return weights
+}
+
+func indexLink(tx storm.Node, link *Link) error {
+	if err := unindexLink(tx, link.ID); err != nil {
+		return err
+	}
+
+	if err := loadText(tx, link); err != nil {
+		return err
+	}
+
+	doc := indexedDoc{Terms: weightTerms(link)}
+	for term, weight := range doc.Terms {
+		postings, err := getPostings(tx, term)
+		if err != nil {
+			return err
+		}
+
+		postings[link.ID] = weight
+		if err := tx.Set(termsBucket, term, postings); err != nil {
+			return err
+		}
+	}
+
+	return tx.Set(docsBucket, link.ID, &doc)
+}
+
+func unindexLink(tx storm.Node, id int) error {
+	var doc indexedDoc
+	if err := tx.Get(docsBucket, id, &doc); err == storm.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for term := range doc.Terms {
+		postings, err := getPostings(tx, term)
+		if err != nil {
+			return err
+		}
+
+		delete(postings, id)
+		if len(postings) == 0 {
+			err = tx.Delete(termsBucket, term)
+		} else {
+			err = tx.Set(termsBucket, term, postings)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Delete(docsBucket, id)
+}
+
+func getPostings(db storm.Node, term string) (map[int]int, error) {
+	postings := map[int]int{}
+	err := db.Get(termsBucket, term, &postings)
+	if err == storm.ErrNotFound {
+		return map[int]int{}, nil
+	}
+
+	return postings, err
+}
+
+func countDocs(db storm.Node) (int, error) {
+	count, err := db.Count(&Link{})
+	if err == storm.ErrNotFound {
+		return 0, nil
+	}
+
+	return count, err
+}
+
+//scoreTerms ranks links containing at least one of the terms
+//using tf-idf over weighted term frequencies.
+func scoreTerms(db storm.Node, terms []string) (map[int]float64, error) {
+	scores := map[int]float64{}
+	total, err := countDocs(db)
+	if err != nil || total == 0 {
+		return scores, err
+	}
+
+	for _, term := range terms {
+		postings, err := getPostings(db, term)
+		if err != nil {
+			return nil, err
+		}
+
+		idf := math.Log(1 + float64(total)/float64(len(postings)+1))
+		for id, weight := range postings {
+			scores[id] += (1 + math.Log(float64(weight))) * idf
+		}
+	}
+
+	return scores, nil
+}
+
+func search(ctx context.Context, db *storm.DB, query string, filter LinkFilter) ([]SearchResult, error) {
+	terms := uniqueTerms(tokenize(query))
+	scores, err := scoreTerms(db, terms)
+	if err != nil || len(scores) == 0 {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+
+	matchers, err := filterMatchers(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Link
+	matchers = append(matchers, q.In("ID", ids))
+	err = db.Select(matchers...).Find(&found)
+	if err != nil && err != storm.ErrNotFound {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(found))
+	for _, link := range found {
+		if err := loadText(db, &link); err != nil {
+			return nil, err
+		}
+
+		snippet, matches := makeSnippet(link, terms)
+		results = append(results, SearchResult{
+			Link:    link,
+			Score:   scores[link.ID],
+			Snippet: snippet,
+			Matches: matches,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Link.ID < results[j].Link.ID
+		}
+
+		return results[i].Score > results[j].Score
+	})
+
+	return results, nil
+}
+
+func reindex(ctx context.Context, db *storm.DB) error {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	for _, bucket := range []string{termsBucket, docsBucket} {
+		if err := tx.Drop(bucket); err != nil && err != storm.ErrNotFound {
+			return err
+		}
+	}
+
+	var all []Link
+	if err := tx.All(&all); err != nil {
+		return err
+	}
+
+	for i := range all {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := indexLink(tx, &all[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+
+	return result
+}
+
+//makeSnippet picks the part of the link text around the first
+//occurrence of any term and reports where the terms are in it.
+func makeSnippet(link Link, terms []string) (string, [][2]int) {
+	texts := []string{link.Text, link.Description, link.Title, link.Note, link.annotationsText()}
+	for _, text := range texts {
+		if start, ok := firstMatch(text, terms); ok {
+			snippet := cutSnippet(text, start)
+			return snippet, findMatches(snippet, terms)
+		}
+	}
+
+	snippet := cutSnippet(link.Description, 0)
+	return snippet, nil
+}
+
+func firstMatch(text string, terms []string) (int, bool) {
+	matches := findMatches(text, terms)
+	if len(matches) == 0 {
+		return 0, false
+	}
+
+	return matches[0][0], true
+}
+
+func cutSnippet(text string, pos int) string {
+	if len(text) <= snippetWidth {
+		return text
+	}
+
+	start := pos - snippetWidth/4
+	if start < 0 {
+		start = 0
+	}
+
+	end := start + snippetWidth
+	if end > len(text) {
+		end = len(text)
+		start = end - snippetWidth
+	}
+
+	start, end = alignToWords(text, start, end)
+	snippet := strings.TrimSpace(text[start:end])
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+
+	if end < len(text) {
+		snippet = snippet + "..."
+	}
+
+	return snippet
+}
+
+//alignToWords moves bounds of the snippet to spaces between words,
+//text without spaces nearby is cut between runes at least.
+func alignToWords(text string, start, end int) (int, int) {
+	if start > 0 {
+		if i := strings.IndexByte(text[start:end], ' '); i >= 0 {
+			start += i + 1
+		}
+	}
+
+	if end < len(text) {
+		if i := strings.LastIndexByte(text[start:end], ' '); i >= 0 {
+			end = start + i
+		}
+	}
+
+	for start < end && !utf8.RuneStart(text[start]) {
+		start++
+	}
+
+	for end < len(text) && end > start && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	return start, end
+}
+
+//findMatches returns sorted offsets of whole words in text
+//that are equal to one of the terms, ignoring case.
+func findMatches(text string, terms []string) [][2]int {
+	var matches [][2]int
+	wanted := map[string]bool{}
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	start := -1
+	for i, r := range text + " " {
+		if !isSeparator(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 && wanted[strings.ToLower(text[start:i])] {
+			matches = append(matches, [2]int{start, i})
+		}
+		start = -1
+	}
+
+	return matches
+}
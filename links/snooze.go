@@ -0,0 +1,88 @@
+package links
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Snoozed tells whether the link is hidden until later than now.
+func (me *Link) Snoozed(now time.Time) bool {
+	return me.SnoozedUntil.After(now)
+}
+
+//Snooze hides the link until the time, when remind is set
+//the link also gets a reminder at that time.
+func (me *Link) Snooze(until time.Time, remind bool) {
+	me.SnoozedUntil = until
+	if remind {
+		me.RemindAt = until
+	}
+}
+
+//Unsnooze shows the link again and clears its reminder.
+func (me *Link) Unsnooze() {
+	me.SnoozedUntil = time.Time{}
+	me.RemindAt = time.Time{}
+}
+
+var delayPattern = regexp.MustCompile(`^(\d+)\s*(h|d|w|mo|y)$`)
+
+//dateLayouts are layouts ParseTime accepts for absolute times.
+var dateLayouts = []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04", time.RFC3339}
+
+//ParseTime turns delay relative to now, such as 12h, 3d, 2w, 1mo
+//or 1y, or a date, such as 2006-01-02 or 2006-01-02 15:04 in
+//local time, into the moment it refers to.
+func ParseTime(text string, now time.Time) (time.Time, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if match := delayPattern.FindStringSubmatch(text); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Invalid delay %q", text)
+		}
+
+		switch match[2] {
+		case "h":
+			return now.Add(time.Duration(n) * time.Hour), nil
+		case "d":
+			return now.AddDate(0, 0, n), nil
+		case "w":
+			return now.AddDate(0, 0, 7*n), nil
+		case "mo":
+			return now.AddDate(0, n, 0), nil
+		case "y":
+			return now.AddDate(n, 0, 0), nil
+		}
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, text, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid time %q, use delay like 3d, 2w, 1mo or date like 2006-01-02", text)
+}
+
+//timeMatcher matches time fields which are set and
+//either later than now or, when passed is set, not later.
+type timeMatcher struct {
+	now    time.Time
+	passed bool
+}
+
+func (me timeMatcher) MatchField(v interface{}) (bool, error) {
+	t, ok := v.(time.Time)
+	if !ok {
+		return false, fmt.Errorf("Expected time.Time, got %T", v)
+	}
+
+	if t.IsZero() {
+		return false, nil
+	}
+
+	return t.After(me.now) != me.passed, nil
+}
@@ -0,0 +1,136 @@
+package links
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+//Counts holds number of links in a group, e.g. in a list.
+type Counts struct {
+	Total      int `json:"total"`
+	Archived   int `json:"archived"`
+	Unarchived int `json:"unarchived"`
+}
+
+//WeekStats holds number of links added and archived during a week.
+type WeekStats struct {
+	//Week is ISO 8601 week, e.g. 2019-W18.
+	Week     string `json:"week"`
+	Added    int    `json:"added"`
+	Archived int    `json:"archived"`
+}
+
+//Stats summarizes a set of links.
+type Stats struct {
+	Counts
+	Lists   map[string]Counts `json:"lists"`
+	Sources map[string]Counts `json:"sources"`
+	//Weeks is ordered from the oldest week to the most recent one.
+	Weeks []WeekStats `json:"weeks"`
+	//OldestUnread is the oldest non-archived link, if any.
+	OldestUnread *LinkSummary `json:"oldestUnread,omitempty"`
+}
+
+//LinkSummary identifies a link in stats, leaving out its contents.
+type LinkSummary struct {
+	ID      int        `json:"id"`
+	URL     string     `json:"url"`
+	Title   string     `json:"title"`
+	Created *time.Time `json:"created,omitempty"`
+}
+
+//NewStats computes statistics for provided links.
+func NewStats(all []Link) *Stats {
+	stats := &Stats{
+		Lists:   map[string]Counts{},
+		Sources: map[string]Counts{},
+	}
+
+	weeks := map[string]*WeekStats{}
+	week := func(t time.Time) *WeekStats {
+		year, number := t.ISOWeek()
+		key := fmt.Sprintf("%d-W%02d", year, number)
+		if weeks[key] == nil {
+			weeks[key] = &WeekStats{Week: key}
+		}
+		return weeks[key]
+	}
+
+	var oldest *Link
+	for i := range all {
+		link := &all[i]
+		stats.Counts.add(link)
+		stats.Lists[link.List] = stats.Lists[link.List].with(link)
+		stats.Sources[link.Source] = stats.Sources[link.Source].with(link)
+
+		if !link.Created.IsZero() {
+			week(link.Created).Added++
+		}
+
+		if link.Archived && !link.ArchivedAt.IsZero() {
+			week(link.ArchivedAt).Archived++
+		}
+
+		if !link.Archived && isOlder(link, oldest) {
+			oldest = link
+		}
+	}
+
+	if oldest != nil {
+		stats.OldestUnread = summarize(oldest)
+	}
+
+	for _, w := range weeks {
+		stats.Weeks = append(stats.Weeks, *w)
+	}
+
+	sort.Slice(stats.Weeks, func(i, j int) bool {
+		return stats.Weeks[i].Week < stats.Weeks[j].Week
+	})
+
+	return stats
+}
+
+func summarize(link *Link) *LinkSummary {
+	summary := &LinkSummary{ID: link.ID, Title: link.Title}
+	if link.URL != nil {
+		summary.URL = link.URL.String()
+	}
+
+	if !link.Created.IsZero() {
+		created := link.Created
+		summary.Created = &created
+	}
+
+	return summary
+}
+
+func (me *Counts) add(link *Link) {
+	me.Total++
+	if link.Archived {
+		me.Archived++
+	} else {
+		me.Unarchived++
+	}
+}
+
+func (me Counts) with(link *Link) Counts {
+	me.add(link)
+	return me
+}
+
+//isOlder tells whether link was created before other one,
+//links created before creation time was recorded are
+//ordered by ID.
+func isOlder(link *Link, other *Link) bool {
+	if other == nil {
+		return true
+	}
+
+	if link.Created.Equal(other.Created) {
+		return link.ID < other.ID
+	}
+
+	return link.Created.Before(other.Created)
+}
@@ -0,0 +1,73 @@
+package links
+
+import (
+	"fmt"
+	"strings"
+)
+
+//NormalizeTag brings tag to the form it is stored in:
+//lowercased, without surrounding spaces.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+//HasTag tells whether link is tagged with tag.
+func (me *Link) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, t := range me.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+//AddTags tags link with provided tags, skipping those
+//the link already has.
+func (me *Link) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" && !me.HasTag(tag) {
+			me.Tags = append(me.Tags, tag)
+		}
+	}
+}
+
+//RemoveTags removes provided tags from link.
+func (me *Link) RemoveTags(tags ...string) {
+	kept := me.Tags[:0]
+	for _, t := range me.Tags {
+		if !containsTag(tags, t) {
+			kept = append(kept, t)
+		}
+	}
+
+	me.Tags = kept
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if NormalizeTag(t) == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+type tagMatcher string
+
+func (me tagMatcher) MatchField(v interface{}) (bool, error) {
+	tags, ok := v.([]string)
+	if !ok {
+		return false, fmt.Errorf("Expected []string, got %T", v)
+	}
+
+	for _, tag := range tags {
+		if tag == string(me) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,42 @@
+package links
+
+import (
+	"github.com/asdine/storm"
+)
+
+//textsBucket keeps page texts apart from links, so finding
+//links doesn't decode page texts of all of them.
+const textsBucket = "page_texts"
+
+//saveText stores page text of the link, empty text
+//leaves the stored one as it is.
+func saveText(tx storm.Node, link *Link) error {
+	if link.Text == "" {
+		return nil
+	}
+
+	return tx.Set(textsBucket, link.ID, link.Text)
+}
+
+//loadText fills page text of the link unless it has one.
+func loadText(tx storm.Node, link *Link) error {
+	if link.Text != "" {
+		return nil
+	}
+
+	err := tx.Get(textsBucket, link.ID, &link.Text)
+	if err == storm.ErrNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func deleteText(tx storm.Node, id int) error {
+	err := tx.Delete(textsBucket, id)
+	if err == storm.ErrNotFound {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,103 @@
+package links
+
+import (
+	"fmt"
+	"regexp"
+	"unicode"
+
+	"github.com/asdine/storm/q"
+)
+
+//titleMatcher creates a matcher for Title field that
+//matches provided title string according to mode.
+func titleMatcher(title string, mode TitleMode) (q.Matcher, error) {
+	if mode&MatchRegex != 0 && mode&MatchFuzzy != 0 {
+		return nil, fmt.Errorf("Title can't be matched as regex and fuzzy at once")
+	}
+
+	if mode&MatchFuzzy != 0 {
+		return q.NewFieldMatcher("Title", fuzzyMatcher(title)), nil
+	}
+
+	pattern := title
+	if mode&MatchRegex == 0 {
+		pattern = regexp.QuoteMeta(title)
+	}
+
+	if mode&IgnoreCase != 0 {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid title pattern: %s", err)
+	}
+
+	return q.NewFieldMatcher("Title", &regexpMatcher{re}), nil
+}
+
+type regexpMatcher struct {
+	re *regexp.Regexp
+}
+
+func (me *regexpMatcher) MatchField(v interface{}) (bool, error) {
+	title, ok := v.(string)
+	if !ok {
+		return false, fmt.Errorf("Expected string, got %T", v)
+	}
+
+	return me.re.MatchString(title), nil
+}
+
+type fuzzyMatcher string
+
+func (me fuzzyMatcher) MatchField(v interface{}) (bool, error) {
+	title, ok := v.(string)
+	if !ok {
+		return false, fmt.Errorf("Expected string, got %T", v)
+	}
+
+	_, ok = FuzzyScore(string(me), title)
+	return ok, nil
+}
+
+//FuzzyScore tells whether all non-space runes of the pattern
+//appear in the text in the same order, ignoring case. Matches
+//at the start of words and runs of consecutive runes score higher.
+func FuzzyScore(pattern string, text string) (int, bool) {
+	needle := []rune(pattern)
+	score, position, run := 0, 0, 0
+	previous := ' '
+	skipSpaces := func() {
+		for position < len(needle) && unicode.IsSpace(needle[position]) {
+			position++
+		}
+	}
+
+	for _, r := range text {
+		skipSpaces()
+		if position == len(needle) {
+			break
+		}
+
+		if unicode.ToLower(r) == unicode.ToLower(needle[position]) {
+			score++
+			if run > 0 {
+				score += 2 * run
+			}
+			if !unicode.IsLetter(previous) && !unicode.IsDigit(previous) {
+				score += 3
+			}
+
+			run++
+			position++
+		} else {
+			run = 0
+		}
+
+		previous = r
+	}
+
+	skipSpaces()
+	return score, position == len(needle)
+}
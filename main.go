@@ -1,27 +1,75 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/dikeert/linkman/cmd"
-	"github.com/dikeert/linkman/data"
 )
 
-const dataDir = "linkman"
-const dataFile = "data.db"
+//Exit codes, see 'linkman --help'.
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitDataHome    = 3
+	exitInvalidURL  = 4
+	exitDuplicate   = 5
+	exitFetchFailed = 6
+	exitNotFound    = 7
+	exitInterrupted = 130
+)
 
 func main() {
-	if err := data.EnsureDataHome(dataDir); err == nil {
-		if dataPath, err := data.GetFilePath(dataDir, dataFile); err == nil {
-			cmd.Execute(dataPath, os.Args[1:])
-			os.Exit(0)
-		} else {
-			fmt.Fprintln(os.Stderr, err)
-		}
+	os.Exit(run())
+}
+
+func run() int {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go func() {
+		<-signals
+		//second signal kills the process right away
+		signal.Stop(signals)
+		cancel()
+	}()
+
+	//empty path makes cmd use the database in XDG_DATA_HOME
+	err := cmd.ExecuteContext(ctx, "", os.Args[1:])
+	if err == nil {
+		return exitOK
+	} else if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "Interrupted")
 	} else {
 		fmt.Fprintln(os.Stderr, err)
 	}
 
-	os.Exit(3)
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, cmd.ErrInvalidURL):
+		return exitInvalidURL
+	case errors.Is(err, cmd.ErrDuplicate):
+		return exitDuplicate
+	case errors.Is(err, cmd.ErrFetchFailed):
+		return exitFetchFailed
+	case errors.Is(err, cmd.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, cmd.ErrDataHome):
+		return exitDataHome
+	}
+
+	return exitError
 }
@@ -1,53 +1,169 @@
 package pages
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/net/html"
 )
 
+const maxTextLength = 64 * 1024
+
+//Page holds the data extracted from a webpage.
+type Page struct {
+	Title       string
+	Description string
+	Text        string
+}
+
+//Option changes how pages are fetched.
+type Option func(*options)
+
+type options struct {
+	client    *http.Client
+	userAgent string
+}
+
+//WithClient makes requests using the client instead
+//of http.DefaultClient, e.g. one with timeout.
+func WithClient(client *http.Client) Option {
+	return func(me *options) {
+		me.client = client
+	}
+}
+
+//WithUserAgent makes requests carry the userAgent, empty
+//one leaves default User-Agent of Go.
+func WithUserAgent(userAgent string) Option {
+	return func(me *options) {
+		me.userAgent = userAgent
+	}
+}
+
 //FetchTitle retrives the title for a webpage located at specified URL.
-func FetchTitle(url *url.URL) (string, error) {
-	resp, err := http.Get(url.String())
+func FetchTitle(ctx context.Context, url *url.URL, opts ...Option) (string, error) {
+	page, err := FetchPage(ctx, url, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	if page.Title == "" {
+		return "", fmt.Errorf("Unable to find title tag")
+	}
+
+	return page.Title, nil
+}
+
+//FetchPage retrieves a webpage located at specified URL and extracts
+//its title, description and readable text. The request is cancelled
+//when ctx is done.
+func FetchPage(ctx context.Context, url *url.URL, opts ...Option) (*Page, error) {
+	fetchOpts := &options{client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(fetchOpts)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
 	if err != nil {
-		return "", fmt.Errorf("Unable to fetch web page: %s", err)
+		return nil, fmt.Errorf("Unable to fetch web page: %s", err)
+	}
+
+	if fetchOpts.userAgent != "" {
+		req.Header.Set("User-Agent", fetchOpts.userAgent)
+	}
+
+	resp, err := fetchOpts.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch web page: %s", err)
 	}
 
 	defer resp.Body.Close()
-	return getHTMLTitle(resp.Body)
+	return parsePage(resp.Body)
 }
 
-func getHTMLTitle(r io.Reader) (string, error) {
+func parsePage(r io.Reader) (*Page, error) {
 	doc, err := html.Parse(r)
 	if err != nil {
-		return "", fmt.Errorf("Unable to parse HTML: %s", err)
+		return nil, fmt.Errorf("Unable to parse HTML: %s", err)
 	}
 
-	result, ok := traverse(doc)
-	if !ok {
-		return result, fmt.Errorf("Unable to find title tag")
+	page := &Page{}
+	var text strings.Builder
+	extract(doc, page, &text)
+	page.Text = truncate(collapseSpaces(text.String()), maxTextLength)
+	return page, nil
+}
+
+//truncate cuts s to at most max bytes without cutting a rune.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
 	}
 
-	return result, nil
+	end := max
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+
+	return s[:end]
+}
+
+func isElement(n *html.Node, name string) bool {
+	return n.Type == html.ElementNode && n.Data == name
 }
 
-func isTitleElement(n *html.Node) bool {
-	return n.Type == html.ElementNode && n.Data == "title"
+func isSkippedElement(n *html.Node) bool {
+	return isElement(n, "script") ||
+		isElement(n, "style") ||
+		isElement(n, "noscript") ||
+		isElement(n, "template")
 }
 
-func traverse(n *html.Node) (string, bool) {
-	if isTitleElement(n) {
-		return n.FirstChild.Data, true
+func extract(n *html.Node, page *Page, text *strings.Builder) {
+	switch {
+	case isElement(n, "title"):
+		if page.Title == "" && n.FirstChild != nil {
+			page.Title = strings.TrimSpace(n.FirstChild.Data)
+		}
+		return
+	case isElement(n, "meta"):
+		if page.Description == "" && isDescription(n) {
+			page.Description = strings.TrimSpace(getAttr(n, "content"))
+		}
+		return
+	case isSkippedElement(n):
+		return
+	case n.Type == html.TextNode:
+		text.WriteString(n.Data)
+		text.WriteString(" ")
 	}
 
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if result, ok := traverse(c); ok {
-			return result, ok
+		extract(c, page, text)
+	}
+}
+
+func isDescription(n *html.Node) bool {
+	name := strings.ToLower(getAttr(n, "name"))
+	property := strings.ToLower(getAttr(n, "property"))
+	return name == "description" || property == "og:description"
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
 		}
 	}
 
-	return "", false
+	return ""
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
 }
@@ -0,0 +1,579 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dikeert/linkman/linkman"
+	"github.com/dikeert/linkman/links"
+)
+
+//Server exposes links.Store over JSON REST API:
+//
+// GET    /links              - links, accepts the same filters as 'list'
+// POST   /links              - creates new link, mirrors 'add'
+// GET    /links/{id}         - single link
+// PATCH  /links/{id}         - changes title, list, tags, priority, rating,
+//                              note or archived status
+// POST   /links/{id}/archive - archives a link
+// GET    /lists              - lists with number of links in them
+//
+//and HTML UI on the rest of paths, see registerUI.
+//
+//When token is set, every API request should carry it
+//in "Authorization: Bearer <token>" header.
+type Server struct {
+	store links.Store
+	token string
+	mux   *http.ServeMux
+	pages map[string]*template.Template
+
+	client *linkman.Client
+}
+
+//Link is JSON representation of links.Link.
+type Link struct {
+	ID          int          `json:"id"`
+	URL         string       `json:"url"`
+	Source      string       `json:"source"`
+	Title       string       `json:"title"`
+	Description string       `json:"description,omitempty"`
+	List        string       `json:"list"`
+	Tags        []string     `json:"tags"`
+	Archived    bool         `json:"archived"`
+	Priority    int          `json:"priority"`
+	Rating      int          `json:"rating,omitempty"`
+	Note        string       `json:"note,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+	Created     *time.Time   `json:"created,omitempty"`
+	ArchivedAt  *time.Time   `json:"archivedAt,omitempty"`
+}
+
+//Annotation is JSON representation of links.Annotation.
+type Annotation struct {
+	Text    string    `json:"text"`
+	Created time.Time `json:"created"`
+}
+
+//List is JSON representation of a list of links.
+type List struct {
+	Name string `json:"name"`
+	links.Counts
+}
+
+//NewLink is a request to create new link.
+type NewLink struct {
+	URL   string   `json:"url"`
+	Title string   `json:"title"`
+	List  string   `json:"list"`
+	Tags  []string `json:"tags"`
+	//Priority is from 0 to links.MaxPriority.
+	Priority int `json:"priority"`
+	//SkipFetch disables fetching the page, as --skip-title-fetch does.
+	SkipFetch bool `json:"skipFetch"`
+	//Force allows duplicates, as --force does.
+	Force bool `json:"force"`
+}
+
+//LinkPatch is a request to change a link, only provided fields change.
+type LinkPatch struct {
+	Title    *string   `json:"title"`
+	List     *string   `json:"list"`
+	Tags     *[]string `json:"tags"`
+	Archived *bool     `json:"archived"`
+	Priority *int      `json:"priority"`
+	Rating   *int      `json:"rating"`
+	Note     *string   `json:"note"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+//apiError is an error that knows HTTP status it should be reported with.
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (me *apiError) Error() string {
+	return me.msg
+}
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &apiError{status: status, msg: fmt.Sprintf(format, args...)}
+}
+
+//New creates new Server over the store. Empty token disables
+//authentication, opts configure how links are added, e.g. the
+//default list.
+func New(store links.Store, token string, opts ...linkman.Option) *Server {
+	me := &Server{
+		store:  store,
+		token:  token,
+		mux:    http.NewServeMux(),
+		client: linkman.New(store, opts...),
+	}
+
+	me.mux.HandleFunc("/links", me.api(me.handleLinks))
+	me.mux.HandleFunc("/links/", me.api(me.handleLink))
+	me.mux.HandleFunc("/lists", me.api(me.handleLists))
+	me.registerUI()
+	return me
+}
+
+func (me *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	me.mux.ServeHTTP(w, r)
+}
+
+//api makes handler require the token in Authorization header.
+func (me *Server) api(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if me.token != "" && (!strings.HasPrefix(header, "Bearer ") ||
+			!me.validToken(strings.TrimPrefix(header, "Bearer "))) {
+
+			w.Header().Set("WWW-Authenticate", `Bearer realm="linkman"`)
+			writeError(w, errorf(http.StatusUnauthorized, "Invalid or missing token"))
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func (me *Server) validToken(provided string) bool {
+	if me.token == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(me.token)) == 1
+}
+
+func (me *Server) handleLinks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		me.respond(w, http.StatusOK)(me.findLinks(r.Context(), r.URL.Query()))
+	case http.MethodPost:
+		me.respond(w, http.StatusCreated)(me.createLink(r))
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (me *Server) handleLink(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/links/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) > 2 {
+		writeError(w, errorf(http.StatusNotFound, "Not found"))
+		return
+	}
+
+	if len(parts) == 2 {
+		if parts[1] != "archive" {
+			writeError(w, errorf(http.StatusNotFound, "Not found"))
+		} else if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+		} else {
+			archived := true
+			me.respond(w, http.StatusOK)(me.patchLink(r.Context(), id, &LinkPatch{Archived: &archived}))
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		me.respond(w, http.StatusOK)(me.findLink(r.Context(), id))
+	case http.MethodPatch:
+		var patch LinkPatch
+		if err := decode(r, &patch); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		me.respond(w, http.StatusOK)(me.patchLink(r.Context(), id, &patch))
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPatch)
+	}
+}
+
+func (me *Server) handleLists(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	me.respond(w, http.StatusOK)(me.findLists(r.Context()))
+}
+
+//respond writes result of a handler: either the value
+//with provided status or an error.
+func (me *Server) respond(w http.ResponseWriter, status int) func(interface{}, error) {
+	return func(value interface{}, err error) {
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, status, value)
+	}
+}
+
+func (me *Server) findLinks(ctx context.Context, params url.Values) (interface{}, error) {
+	filter, err := parseFilter(params)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := me.store.FindLinks(ctx, filter)
+	if err != nil {
+		return nil, errorf(http.StatusInternalServerError, "Unable to fetch links: %s", err)
+	}
+
+	return toLinks(found), nil
+}
+
+func (me *Server) findLink(ctx context.Context, id int) (interface{}, error) {
+	found, err := me.store.FindLinks(ctx, byID(id))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(found) == 0 {
+		return nil, errorf(http.StatusNotFound, "Link with ID %d not found", id)
+	}
+
+	return toLink(found[0]), nil
+}
+
+func (me *Server) createLink(r *http.Request) (interface{}, error) {
+	var request NewLink
+	if err := decode(r, &request); err != nil {
+		return nil, err
+	}
+
+	link, err := me.addLink(r.Context(), &request)
+	if err != nil {
+		return nil, err
+	}
+
+	return toLink(*link), nil
+}
+
+func (me *Server) addLink(ctx context.Context, request *NewLink) (*links.Link, error) {
+	opts := []linkman.AddOption{linkman.WithTags(request.Tags...)}
+	if request.List != "" {
+		opts = append(opts, linkman.InList(request.List))
+	}
+
+	if request.Title != "" {
+		opts = append(opts, linkman.WithTitle(request.Title))
+	}
+
+	if request.SkipFetch {
+		opts = append(opts, linkman.SkipFetch())
+	}
+
+	if request.Force {
+		opts = append(opts, linkman.AllowDuplicate())
+	}
+
+	if err := links.CheckPriority(request.Priority); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%s", err)
+	}
+	opts = append(opts, linkman.WithPriority(request.Priority))
+
+	link, err := me.client.Add(ctx, request.URL, opts...)
+	switch {
+	case errors.Is(err, linkman.ErrInvalidURL):
+		return nil, errorf(http.StatusBadRequest, "%s", err)
+	case errors.Is(err, linkman.ErrDuplicate):
+		return nil, errorf(http.StatusConflict, "%s", err)
+	case errors.Is(err, linkman.ErrFetchFailed):
+		return nil, errorf(http.StatusBadGateway, "%s", err)
+	}
+
+	return link, err
+}
+
+func (me *Server) patchLink(ctx context.Context, id int, patch *LinkPatch) (interface{}, error) {
+	if err := patch.check(); err != nil {
+		return nil, err
+	}
+
+	updated, err := me.store.UpdateLinks(ctx, byID(id), func(link *links.Link) {
+		patch.apply(link)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(updated) == 0 {
+		return nil, errorf(http.StatusNotFound, "Link with ID %d not found", id)
+	}
+
+	return toLink(updated[0]), nil
+}
+
+//check validates priority and rating of the patch.
+func (me *LinkPatch) check() error {
+	if me.Priority != nil {
+		if err := links.CheckPriority(*me.Priority); err != nil {
+			return errorf(http.StatusBadRequest, "%s", err)
+		}
+	}
+
+	if me.Rating != nil {
+		if err := links.CheckRating(*me.Rating); err != nil {
+			return errorf(http.StatusBadRequest, "%s", err)
+		}
+	}
+
+	return nil
+}
+
+func (me *LinkPatch) apply(link *links.Link) {
+	if me.Title != nil {
+		link.Title = *me.Title
+	}
+
+	if me.List != nil {
+		link.List = *me.List
+	}
+
+	if me.Tags != nil {
+		link.Tags = nil
+		link.AddTags(*me.Tags...)
+	}
+
+	if me.Priority != nil {
+		link.Priority = *me.Priority
+	}
+
+	if me.Rating != nil {
+		link.Rating = *me.Rating
+	}
+
+	if me.Note != nil {
+		link.Note = *me.Note
+	}
+
+	if me.Archived != nil && *me.Archived != link.Archived {
+		link.Archived = *me.Archived
+		link.ArchivedAt = time.Time{}
+		if link.Archived {
+			link.ArchivedAt = time.Now()
+		}
+	}
+}
+
+func (me *Server) findLists(ctx context.Context) ([]List, error) {
+	found, err := me.store.FindLinks(ctx, links.NewFilter(links.FromList("*")))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := links.NewStats(found)
+	lists := make([]List, 0, len(stats.Lists))
+	for name, counts := range stats.Lists {
+		lists = append(lists, List{Name: name, Counts: counts})
+	}
+
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].Name < lists[j].Name
+	})
+
+	return lists, nil
+}
+
+func byID(id int) links.LinkFilter {
+	return links.NewFilter(links.WithIDs(id))
+}
+
+//parseFilter builds links filter from query parameters, which
+//mirror flags of 'list' command: list, source, title, tag, q,
+//min-priority, rated, archived and snoozed (false, true or all),
+//sort, reverse, limit and offset.
+func parseFilter(params url.Values) (links.LinkFilter, error) {
+	var conds []links.FilterCondition
+	var query *links.Query
+
+	if text := params.Get("q"); text != "" {
+		parsed, err := links.ParseQuery(text)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "%s", err)
+		}
+
+		query = parsed
+		conds = append(conds, links.MatchQuery(query))
+	}
+
+	if source := params.Get("source"); source != "" {
+		conds = append(conds, links.WithSource(source))
+	}
+
+	if title := params.Get("title"); title != "" {
+		conds = append(conds, links.WithTitle(title, links.IgnoreCase))
+	}
+
+	if tag := params.Get("tag"); tag != "" {
+		conds = append(conds, links.WithTag(tag))
+	}
+
+	if list := params.Get("list"); list != "" {
+		conds = append(conds, links.FromList(list))
+	}
+
+	if params.Get("rated") == "true" {
+		conds = append(conds, links.IsRated())
+	}
+
+	switch params.Get("archived") {
+	case "true":
+		conds = append(conds, links.OnlyArchived())
+	case "all":
+		conds = append(conds, links.IncludeArchived())
+	case "", "false":
+		if query == nil || !query.Uses("archived") {
+			conds = append(conds, links.NoArchived())
+		}
+	default:
+		return nil, errorf(http.StatusBadRequest,
+			"Invalid archived value, expected true, false or all")
+	}
+
+	switch params.Get("snoozed") {
+	case "true":
+		conds = append(conds, links.OnlySnoozed())
+	case "all":
+	case "", "false":
+		if query == nil || !query.Uses("snoozed") {
+			conds = append(conds, links.NoSnoozed())
+		}
+	default:
+		return nil, errorf(http.StatusBadRequest,
+			"Invalid snoozed value, expected true, false or all")
+	}
+
+	if sortBy := params.Get("sort"); sortBy != "" {
+		fields := strings.Split(sortBy, ",")
+		for _, field := range fields {
+			if err := links.CheckSortField(field); err != nil {
+				return nil, errorf(http.StatusBadRequest, "%s", err)
+			}
+		}
+
+		conds = append(conds, links.SortBy(fields...))
+	}
+
+	if params.Get("reverse") == "true" {
+		conds = append(conds, links.Reverse())
+	}
+
+	for name, condition := range map[string]func(int) links.FilterCondition{
+		"limit":        links.Limit,
+		"offset":       links.Skip,
+		"min-priority": links.MinPriority,
+	} {
+		if value := params.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, errorf(http.StatusBadRequest, "Invalid %s value", name)
+			}
+
+			conds = append(conds, condition(n))
+		}
+	}
+
+	return links.NewFilter(conds...), nil
+}
+
+func toLinks(found []links.Link) []Link {
+	result := make([]Link, 0, len(found))
+	for _, link := range found {
+		result = append(result, toLink(link))
+	}
+
+	return result
+}
+
+func toLink(link links.Link) Link {
+	result := Link{
+		ID:          link.ID,
+		URL:         link.URL.String(),
+		Source:      link.Source,
+		Title:       link.Title,
+		Description: link.Description,
+		List:        link.List,
+		Tags:        link.Tags,
+		Archived:    link.Archived,
+		Priority:    link.Priority,
+		Rating:      link.Rating,
+		Note:        link.Note,
+	}
+
+	for _, annotation := range link.Annotations {
+		result.Annotations = append(result.Annotations, Annotation{
+			Text:    annotation.Text,
+			Created: annotation.Created,
+		})
+	}
+
+	if result.Tags == nil {
+		result.Tags = []string{}
+	}
+
+	if !link.Created.IsZero() {
+		result.Created = &link.Created
+	}
+
+	if !link.ArchivedAt.IsZero() {
+		result.ArchivedAt = &link.ArchivedAt
+	}
+
+	return result
+}
+
+func decode(r *http.Request, value interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value); err != nil {
+		return errorf(http.StatusBadRequest, "Invalid request body: %s", err)
+	}
+
+	return nil
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, errorf(http.StatusMethodNotAllowed, "Method not allowed"))
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusOf(err), errorResponse{Error: err.Error()})
+}
+
+func statusOf(err error) int {
+	if apiErr, ok := err.(*apiError); ok {
+		return apiErr.status
+	}
+
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
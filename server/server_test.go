@@ -0,0 +1,269 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dikeert/linkman/links"
+	"github.com/dikeert/linkman/server"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const token = "secret"
+
+func TestServer(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup := openStore(t)
+	defer cleanup()
+
+	ts := httptest.NewServer(server.New(store, token))
+	defer ts.Close()
+
+	status, _ := request(t, ts, "GET", "/links", "", "")
+	assert.Equal(http.StatusUnauthorized, status, "should require token")
+
+	status, body := request(t, ts, "POST", "/links", token,
+		`{"url": "https://example.com/a", "title": "Rust book", "list": "reading", "skipFetch": true}`)
+	assert.Equal(http.StatusCreated, status, string(body))
+
+	var created server.Link
+	assert.NoError(json.Unmarshal(body, &created))
+	assert.Equal("Rust book", created.Title)
+	assert.Equal("reading", created.List)
+	assert.Equal("example", created.Source)
+
+	status, _ = request(t, ts, "POST", "/links", token,
+		`{"url": "https://example.com/a", "title": "Again", "skipFetch": true}`)
+	assert.Equal(http.StatusConflict, status, "should reject duplicates")
+
+	status, _ = request(t, ts, "POST", "/links", token,
+		`{"url": "not a url", "skipFetch": true}`)
+	assert.Equal(http.StatusBadRequest, status, "should reject invalid URL")
+
+	request(t, ts, "POST", "/links", token,
+		`{"url": "https://example.org/b", "title": "Go blog", "skipFetch": true}`)
+
+	var found []server.Link
+	status, body = request(t, ts, "GET", "/links?q=list:*+rust", token, "")
+	assert.Equal(http.StatusOK, status, string(body))
+	assert.NoError(json.Unmarshal(body, &found))
+	assert.Equal(1, len(found), "should filter by query")
+
+	status, body = request(t, ts, "PATCH", "/links/1", token, `{"title": "Go book", "tags": ["Go"]}`)
+	assert.Equal(http.StatusOK, status, string(body))
+	assert.NoError(json.Unmarshal(body, &created))
+	assert.Equal("Go book", created.Title)
+	assert.Equal([]string{"go"}, created.Tags)
+
+	status, body = request(t, ts, "PATCH", "/links/2", token, `{"priority": 2, "rating": 4}`)
+	assert.Equal(http.StatusOK, status, string(body))
+
+	status, body = request(t, ts, "PATCH", "/links/2", token, `{"note": "Read *twice*"}`)
+	assert.NoError(json.Unmarshal(body, &created))
+	assert.Equal("Read *twice*", created.Note)
+
+	status, _ = request(t, ts, "PATCH", "/links/2", token, `{"rating": 6}`)
+	assert.Equal(http.StatusBadRequest, status, "should reject invalid rating")
+
+	status, body = request(t, ts, "GET", "/links?min-priority=1&rated=true", token, "")
+	assert.NoError(json.Unmarshal(body, &found))
+	if assert.Equal(1, len(found), "should filter by priority and rating") {
+		assert.Equal(2, found[0].Priority)
+		assert.Equal(4, found[0].Rating)
+	}
+
+	status, _ = request(t, ts, "POST", "/links/1/archive", token, "")
+	assert.Equal(http.StatusOK, status)
+
+	status, body = request(t, ts, "GET", "/links?list=reading", token, "")
+	assert.NoError(json.Unmarshal(body, &found))
+	assert.Equal(0, len(found), "should hide archived links")
+
+	_, err := store.UpdateLinks(context.Background(), links.NewFilter(links.WithIDs(2)), func(link *links.Link) {
+		link.Snooze(time.Now().Add(time.Hour), false)
+	})
+	assert.NoError(err)
+
+	for query, expected := range map[string]int{"": 0, "snoozed=all": 1, "snoozed=true": 1, "q=snoozed:true": 1} {
+		status, body = request(t, ts, "GET", "/links?"+query, token, "")
+		assert.NoError(json.Unmarshal(body, &found))
+		assert.Equal(expected, len(found), "should filter snoozed links by %q", query)
+	}
+
+	status, _ = request(t, ts, "GET", "/links?snoozed=maybe", token, "")
+	assert.Equal(http.StatusBadRequest, status)
+
+	status, _ = request(t, ts, "GET", "/links?sort=color", token, "")
+	assert.Equal(http.StatusBadRequest, status, "should reject unknown sort field")
+
+	status, _ = request(t, ts, "POST", "/links/42/archive", token, "")
+	assert.Equal(http.StatusNotFound, status)
+
+	status, _ = request(t, ts, "DELETE", "/links/1", token, "")
+	assert.Equal(http.StatusMethodNotAllowed, status)
+
+	var lists []server.List
+	status, body = request(t, ts, "GET", "/lists", token, "")
+	assert.NoError(json.Unmarshal(body, &lists))
+	assert.Equal(2, len(lists))
+	assert.Equal("default", lists[0].Name)
+	assert.Equal(1, lists[1].Archived)
+}
+
+func request(t *testing.T,
+	ts *httptest.Server,
+	method string,
+	path string,
+	token string,
+	body string) (int, []byte) {
+
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, data
+}
+
+func openStore(t *testing.T) (links.Store, func()) {
+	tmpfile, err := ioutil.TempFile("", "linkman.*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+
+	store, err := links.OpenStore(context.Background(), tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store, func() { os.Remove(tmpfile.Name()) }
+}
+
+func TestUI(t *testing.T) {
+	assert := assert.New(t)
+	store, cleanup := openStore(t)
+	defer cleanup()
+
+	ts := httptest.NewServer(server.New(store, token))
+	defer ts.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(ts.URL + "/?list=reading")
+	assert.NoError(err)
+	assert.Equal(http.StatusSeeOther, resp.StatusCode)
+	assert.Equal("/login?next=%2F%3Flist%3Dreading", resp.Header.Get("Location"),
+		"should ask to login")
+
+	resp, err = client.PostForm(ts.URL+"/login", url.Values{"token": {"wrong"}})
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = client.PostForm(ts.URL+"/login", url.Values{"token": {token}, "next": {"/"}})
+	assert.NoError(err)
+	assert.Equal(http.StatusSeeOther, resp.StatusCode)
+	cookies := resp.Cookies()
+	assert.Equal(1, len(cookies), "should set token cookie")
+
+	//the way bookmarklet posts, page can't be fetched so provided title is used
+	resp, err = client.PostForm(ts.URL+"/add", url.Values{
+		"url":   {"https://example.invalid/page"},
+		"title": {"Unreachable page"},
+		"list":  {"reading"},
+		"token": {token},
+		"back":  {"1"},
+	})
+	assert.NoError(err)
+	assert.Equal(http.StatusSeeOther, resp.StatusCode)
+	assert.Equal("https://example.invalid/page", resp.Header.Get("Location"),
+		"should return to the page")
+
+	req, _ := http.NewRequest("GET", ts.URL+"/?list=reading", nil)
+	req.AddCookie(cookies[0])
+	resp, err = client.Do(req)
+	assert.NoError(err)
+	page, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Contains(string(page), "Unreachable page")
+
+	req, _ = http.NewRequest("POST", ts.URL+"/archive",
+		strings.NewReader(url.Values{"id": {"1"}, "next": {"//evil.com"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(cookies[0])
+	resp, err = client.Do(req)
+	assert.NoError(err)
+	assert.Equal(http.StatusSeeOther, resp.StatusCode)
+	assert.Equal("/", resp.Header.Get("Location"), "should stay on the server")
+
+	found, err := store.FindLinks(context.Background(), links.NewFilter(links.FromList("reading"), links.OnlyArchived()))
+	assert.NoError(err)
+	assert.Equal(1, len(found), "should archive the link")
+
+	for _, path := range []string{"/?list=reading", "/?list=reading&q=unreachable"} {
+		req, _ = http.NewRequest("GET", ts.URL+path, nil)
+		req.AddCookie(cookies[0])
+		resp, err = client.Do(req)
+		assert.NoError(err)
+		page, _ = ioutil.ReadAll(resp.Body)
+		assert.Equal(http.StatusOK, resp.StatusCode)
+		assert.NotContains(string(page), "Unreachable page", "should hide archived link on %s", path)
+	}
+
+	resp, err = client.PostForm(ts.URL+"/add", url.Values{
+		"url":   {"https://example.invalid/later"},
+		"title": {"Snoozed page"},
+		"list":  {"reading"},
+		"token": {token},
+	})
+	assert.NoError(err)
+	assert.Equal(http.StatusSeeOther, resp.StatusCode)
+	updated, err := store.UpdateLinks(context.Background(), links.NewFilter(links.WithIDs(2)), func(link *links.Link) {
+		link.Snooze(time.Now().Add(time.Hour), false)
+	})
+	assert.NoError(err)
+	assert.Equal(1, len(updated), "should snooze the link")
+
+	for _, path := range []string{"/?list=reading", "/?list=reading&q=snoozed"} {
+		req, _ = http.NewRequest("GET", ts.URL+path, nil)
+		req.AddCookie(cookies[0])
+		resp, err = client.Do(req)
+		assert.NoError(err)
+		page, _ = ioutil.ReadAll(resp.Body)
+		assert.NotContains(string(page), "Snoozed page", "should hide snoozed link on %s", path)
+	}
+
+	req, _ = http.NewRequest("GET", ts.URL+"/bookmarklet", nil)
+	req.AddCookie(cookies[0])
+	resp, err = client.Do(req)
+	assert.NoError(err)
+	page, _ = ioutil.ReadAll(resp.Body)
+	assert.Contains(string(page), "javascript:", "should render bookmarklet")
+	assert.Contains(string(page), "linkman: reading")
+}
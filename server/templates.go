@@ -0,0 +1,124 @@
+package server
+
+//Templates of HTML UI, each page defines "content" and "title"
+//rendered by the layout.
+
+const layoutTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{template "title" .}} - linkman</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; color: #222; }
+nav { min-width: 12em; padding: 1em; background: #f4f4f4; min-height: 100vh; }
+nav ul { list-style: none; padding: 0; }
+nav li { margin: .3em 0; }
+nav .count { color: #888; font-size: .85em; }
+main { padding: 1em 2em; flex: 1; max-width: 60em; }
+a { color: #1a5fb4; text-decoration: none; }
+a:hover { text-decoration: underline; }
+form.inline { display: inline; }
+input[type=text], input[type=url], input[type=password] { padding: .3em; }
+.error { color: #a51d2d; background: #fbe9eb; padding: .5em; }
+.link { margin: .8em 0; }
+.link .meta { color: #666; font-size: .85em; }
+.link .snippet { color: #444; font-size: .9em; }
+.link button { font-size: .8em; }
+</style>
+</head>
+<body>
+{{template "content" .}}
+</body>
+</html>
+`
+
+const indexTemplate = `{{define "title"}}{{if .Query}}{{.Query}}{{else}}{{.List}}{{end}}{{end}}
+{{define "content"}}
+<nav>
+<strong>Lists</strong>
+<ul>
+<li><a href="/?list=*">all</a></li>
+{{range .Lists}}<li><a href="/?list={{.Name}}">{{.Name}}</a> <span class="count">{{.Unarchived}}</span></li>
+{{end}}
+</ul>
+<a href="/bookmarklet">Bookmarklet</a>
+</nav>
+<main>
+<form method="get" action="/">
+<input type="hidden" name="list" value="{{.List}}">
+<input type="text" name="q" value="{{.Query}}" placeholder="Search {{.List}}">
+<button type="submit">Search</button>
+</form>
+<form method="post" action="/add">
+<input type="url" name="url" placeholder="URL" required>
+<input type="text" name="title" placeholder="Title (optional)">
+<input type="text" name="list" value="{{if eq .List "*"}}default{{else}}{{.List}}{{end}}" size="10">
+<button type="submit">Add</button>
+</form>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{$next := .Next}}
+{{range .Links}}
+<div class="link">
+<a href="{{.Link.URL}}">{{if .Link.Title}}{{.Link.Title}}{{else}}{{.Link.URL}}{{end}}</a>
+<div class="meta">
+{{.Link.Source}} &middot; {{.Link.List}}{{range .Link.Tags}} &middot; #{{.}}{{end}}
+{{if not .Link.Created.IsZero}} &middot; {{.Link.Created.Format "2006-01-02"}}{{end}}
+<form class="inline" method="post" action="/archive">
+<input type="hidden" name="id" value="{{.Link.ID}}">
+<input type="hidden" name="next" value="{{$next}}">
+<button type="submit">Archive</button>
+</form>
+</div>
+{{if .Snippet}}<div class="snippet">{{.Snippet}}</div>{{end}}
+</div>
+{{else}}
+<p>No links.</p>
+{{end}}
+</main>
+{{end}}
+`
+
+const loginTemplate = `{{define "title"}}Login{{end}}
+{{define "content"}}
+<main>
+<form method="post" action="/login">
+<input type="hidden" name="next" value="{{.Next}}">
+<input type="password" name="token" placeholder="Token" autofocus>
+<button type="submit">Login</button>
+</form>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+</main>
+{{end}}
+`
+
+const bookmarkletTemplate = `{{define "title"}}Bookmarklet{{end}}
+{{define "content"}}
+<nav>
+<a href="/">&larr; Links</a>
+</nav>
+<main>
+<p>Drag a link into bookmarks toolbar, clicking it adds
+current page into the list and brings you back to the page.</p>
+<ul>
+{{range .Bookmarklets}}<li><a href="{{.Code}}">linkman: {{.List}}</a></li>
+{{end}}
+</ul>
+<form method="get" action="/bookmarklet">
+<input type="text" name="list" placeholder="Other list">
+<button type="submit">Make bookmarklet</button>
+</form>
+<p>Bookmarklets carry the token, don't share them.</p>
+</main>
+{{end}}
+`
+
+const messageTemplate = `{{define "title"}}{{.Message}}{{end}}
+{{define "content"}}
+<main>
+<p>{{.Message}}</p>
+{{if .URL}}<p><a href="{{.URL}}">Back to the page</a></p>{{end}}
+<p><a href="/">Links</a></p>
+</main>
+{{end}}
+`
@@ -0,0 +1,369 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dikeert/linkman/links"
+)
+
+//tokenCookie holds the token of logged in UI user.
+const tokenCookie = "linkman_token"
+
+//indexPage is what the main page of UI shows.
+type indexPage struct {
+	Lists []List
+	List  string
+	Query string
+	Links []links.SearchResult
+	Error string
+	//Next is where archive form returns to.
+	Next string
+}
+
+type loginPage struct {
+	Next  string
+	Error string
+}
+
+type bookmarkletPage struct {
+	Lists        []List
+	Bookmarklets []bookmarklet
+}
+
+type bookmarklet struct {
+	List string
+	Code template.URL
+}
+
+type messagePage struct {
+	Message string
+	URL     string
+}
+
+//registerUI adds HTML UI handlers:
+//
+// GET  /            - links of a list, full-text search over them
+// POST /add         - adds a link, used by the form and bookmarklet
+// POST /archive     - archives a link
+// GET  /bookmarklet - bookmarklets adding current page into a list
+// GET  /login       - asks for the token, when it's set
+func (me *Server) registerUI() {
+	me.pages = map[string]*template.Template{}
+	for name, text := range map[string]string{
+		"index":       indexTemplate,
+		"login":       loginTemplate,
+		"bookmarklet": bookmarkletTemplate,
+		"message":     messageTemplate,
+	} {
+		layout := template.Must(template.New("layout").Parse(layoutTemplate))
+		me.pages[name] = template.Must(layout.Parse(text))
+	}
+
+	me.mux.HandleFunc("/", me.ui(me.handleIndex))
+	me.mux.HandleFunc("/add", me.ui(me.handleAdd))
+	me.mux.HandleFunc("/archive", me.ui(me.handleArchive))
+	me.mux.HandleFunc("/bookmarklet", me.ui(me.handleBookmarklet))
+	me.mux.HandleFunc("/login", me.handleLogin)
+}
+
+//ui makes handler require the token either in the cookie
+//set by login page or, for bookmarklet, in the form.
+func (me *Server) ui(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if me.token == "" {
+			handler(w, r)
+			return
+		}
+
+		if cookie, err := r.Cookie(tokenCookie); err == nil && me.validToken(cookie.Value) {
+			handler(w, r)
+			return
+		}
+
+		if r.Method == http.MethodPost && me.validToken(r.PostFormValue("token")) {
+			handler(w, r)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			next := "/login?next=" + url.QueryEscape(r.URL.RequestURI())
+			http.Redirect(w, r, next, http.StatusSeeOther)
+			return
+		}
+
+		me.render(w, http.StatusUnauthorized, "login", &loginPage{
+			Next:  "/",
+			Error: "Invalid or missing token",
+		})
+	}
+}
+
+func (me *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		me.render(w, http.StatusNotFound, "message", &messagePage{Message: "Not found"})
+		return
+	}
+
+	page := &indexPage{
+		List:  r.URL.Query().Get("list"),
+		Query: r.URL.Query().Get("q"),
+		Next:  r.URL.RequestURI(),
+	}
+
+	if page.List == "" {
+		page.List = me.client.DefaultList()
+	}
+
+	me.renderIndex(r.Context(), w, http.StatusOK, page)
+}
+
+func (me *Server) renderIndex(ctx context.Context, w http.ResponseWriter, status int, page *indexPage) {
+	var err error
+	if page.Lists, err = me.findLists(ctx); err == nil {
+		page.Links, err = me.findPageLinks(ctx, page.List, page.Query)
+	}
+
+	if err != nil {
+		status = http.StatusInternalServerError
+		page.Error = err.Error()
+	}
+
+	me.render(w, status, "index", page)
+}
+
+//findPageLinks returns unarchived and unsnoozed links of the list,
+//newest first, or results of full-text search when query is not empty.
+func (me *Server) findPageLinks(ctx context.Context, list string, query string) ([]links.SearchResult, error) {
+	if query != "" {
+		return me.store.Search(ctx, query, links.NewFilter(
+			links.FromList(list),
+			links.NoArchived(),
+			links.NoSnoozed(),
+		))
+	}
+
+	found, err := me.store.FindLinks(ctx, links.NewFilter(
+		links.FromList(list),
+		links.NoArchived(),
+		links.NoSnoozed(),
+		links.SortBy("created"),
+		links.Reverse(),
+	))
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]links.SearchResult, 0, len(found))
+	for _, link := range found {
+		results = append(results, links.SearchResult{Link: link})
+	}
+
+	return results, nil
+}
+
+func (me *Server) handleAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		me.render(w, http.StatusMethodNotAllowed, "message",
+			&messagePage{Message: "Method not allowed"})
+		return
+	}
+
+	request := &NewLink{
+		URL:   strings.TrimSpace(r.PostFormValue("url")),
+		Title: strings.TrimSpace(r.PostFormValue("title")),
+		List:  strings.TrimSpace(r.PostFormValue("list")),
+	}
+
+	link, err := me.addLink(r.Context(), request)
+	if statusOf(err) == http.StatusBadGateway && request.Title != "" {
+		//the page may be unreachable from here, e.g. behind login,
+		//but bookmarklet already provided its title
+		request.SkipFetch = true
+		link, err = me.addLink(r.Context(), request)
+	}
+
+	back := r.PostFormValue("back") != ""
+	if err != nil {
+		status := statusOf(err)
+		if back {
+			me.render(w, status, "message", &messagePage{
+				Message: err.Error(),
+				URL:     request.URL,
+			})
+		} else {
+			list := me.listOrDefault(request.List)
+			me.renderIndex(r.Context(), w, status, &indexPage{
+				List:  list,
+				Error: err.Error(),
+				Next:  "/?list=" + url.QueryEscape(list),
+			})
+		}
+		return
+	}
+
+	if back {
+		http.Redirect(w, r, link.URL.String(), http.StatusSeeOther)
+	} else {
+		http.Redirect(w, r, "/?list="+url.QueryEscape(link.List), http.StatusSeeOther)
+	}
+}
+
+func (me *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		me.render(w, http.StatusMethodNotAllowed, "message",
+			&messagePage{Message: "Method not allowed"})
+		return
+	}
+
+	id, err := strconv.Atoi(r.PostFormValue("id"))
+	if err != nil {
+		me.render(w, http.StatusBadRequest, "message",
+			&messagePage{Message: fmt.Sprintf("Value %s is not an ID", r.PostFormValue("id"))})
+		return
+	}
+
+	archived := true
+	if _, err := me.patchLink(r.Context(), id, &LinkPatch{Archived: &archived}); err != nil {
+		me.render(w, statusOf(err), "message", &messagePage{Message: err.Error()})
+		return
+	}
+
+	http.Redirect(w, r, localPath(r.PostFormValue("next")), http.StatusSeeOther)
+}
+
+func (me *Server) handleBookmarklet(w http.ResponseWriter, r *http.Request) {
+	lists, err := me.findLists(r.Context())
+	if err != nil {
+		me.render(w, http.StatusInternalServerError, "message",
+			&messagePage{Message: err.Error()})
+		return
+	}
+
+	page := &bookmarkletPage{Lists: lists}
+	names := []string{me.client.DefaultList()}
+	for _, list := range lists {
+		if list.Name != names[0] {
+			names = append(names, list.Name)
+		}
+	}
+
+	if list := r.URL.Query().Get("list"); list != "" {
+		names = []string{list}
+	}
+
+	for _, name := range names {
+		page.Bookmarklets = append(page.Bookmarklets, bookmarklet{
+			List: name,
+			Code: me.bookmarkletCode(baseURL(r), name),
+		})
+	}
+
+	me.render(w, http.StatusOK, "bookmarklet", page)
+}
+
+//bookmarkletCode builds javascript: URL which posts
+//current page's URL and title into the list.
+func (me *Server) bookmarkletCode(base string, list string) template.URL {
+	fields, _ := json.Marshal(map[string]string{
+		"list":  list,
+		"token": me.token,
+		"back":  "1",
+	})
+
+	action, _ := json.Marshal(base + "/add")
+	return template.URL("javascript:(function(){" +
+		"var f=document.createElement('form');" +
+		"f.method='POST';f.action=" + string(action) + ";" +
+		"var v=" + string(fields) + ";" +
+		"v.url=location.href;v.title=document.title;" +
+		"for(var k in v){var i=document.createElement('input');" +
+		"i.type='hidden';i.name=k;i.value=v[k];f.appendChild(i);}" +
+		"document.body.appendChild(f);f.submit();})()")
+}
+
+func (me *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	next := localPath(r.FormValue("next"))
+	if me.token == "" {
+		http.Redirect(w, r, next, http.StatusSeeOther)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		me.render(w, http.StatusOK, "login", &loginPage{Next: next})
+		return
+	}
+
+	token := r.PostFormValue("token")
+	if !me.validToken(token) {
+		me.render(w, http.StatusUnauthorized, "login", &loginPage{
+			Next:  next,
+			Error: "Invalid token",
+		})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+//render writes the page only once it's rendered in full,
+//failures are logged and reported as 500.
+func (me *Server) render(w http.ResponseWriter, status int, name string, data interface{}) {
+	var page bytes.Buffer
+	if err := me.pages[name].ExecuteTemplate(&page, "layout", data); err != nil {
+		log.Printf("Unable to render %s page: %s", name, err)
+		http.Error(w, "Unable to render page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	page.WriteTo(w)
+}
+
+//localPath makes sure redirects stay within the server.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") ||
+		strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+
+	return path
+}
+
+func (me *Server) listOrDefault(list string) string {
+	if list == "" {
+		return me.client.DefaultList()
+	}
+
+	return list
+}
+
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,554 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dikeert/linkman/links"
+	"github.com/dikeert/linkman/pages"
+)
+
+const sidebarWidth = 20
+
+//sortOrders are orders 's' cycles through, created
+//is shown newest first.
+var sortOrders = []string{"created", "title", "source", "id"}
+
+//Browser shows lists in a sidebar and links of the chosen
+//list in a table, links are searched and changed in place.
+type Browser struct {
+	Store links.Store
+	//List is the list shown first, all lists when empty.
+	List string
+	//Fetch fetches page to refresh title of a link,
+	//pages.FetchPage when nil.
+	Fetch func(ctx context.Context, url *url.URL) (*pages.Page, error)
+	//Open opens a link, 'o' does nothing when nil.
+	Open func(ctx context.Context, link links.Link) error
+}
+
+type pane int
+
+const (
+	sidebarPane pane = iota
+	tablePane
+)
+
+type browser struct {
+	*Browser
+	ctx      context.Context
+	lists    []string
+	counts   map[string]links.Counts
+	list     int
+	links    []links.Link
+	cursor   int
+	top      int
+	focus    pane
+	sort     int
+	reverse  bool
+	archived bool
+	snoozed  bool
+	query    *input
+	search   bool
+	prompt   *browserPrompt
+	status   string
+}
+
+//browserPrompt asks for a value, e.g. new title of a link.
+type browserPrompt struct {
+	label  string
+	answer *input
+	done   func(answer string) error
+}
+
+//Run shows the browser until user quits it with 'q'.
+func (me *Browser) Run(ctx context.Context, term Terminal) error {
+	state := &browser{Browser: me, ctx: ctx, query: newInput(""), focus: tablePane}
+	if err := state.loadLists(); err != nil {
+		return interrupted(ctx, err)
+	}
+
+	state.list = state.indexOfList(me.List)
+	if err := state.loadLinks(); err != nil {
+		return interrupted(ctx, err)
+	}
+
+	for {
+		if err := term.Draw(state.view(term.Size())); err != nil {
+			return err
+		}
+
+		key, err := term.ReadKey()
+		if err != nil {
+			return interrupted(ctx, err)
+		}
+
+		quit, err := state.handle(key)
+		if quit {
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil {
+			state.status = err.Error()
+		}
+	}
+}
+
+//interrupted returns error of the context when it is done,
+//the error is caused by the context then.
+func interrupted(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+func (me *browser) handle(key Key) (bool, error) {
+	me.status = ""
+	if me.prompt != nil {
+		return false, me.handlePrompt(key)
+	}
+
+	if me.search {
+		return false, me.handleSearch(key)
+	}
+
+	switch {
+	case key == Rune('q') || key == Ctrl('c'):
+		return true, nil
+	case key == KeyTab:
+		me.focus = 1 - me.focus
+	case key == KeyLeft || key == Rune('h'):
+		me.focus = sidebarPane
+	case key == KeyRight || key == Rune('l'):
+		me.focus = tablePane
+	case key == KeyUp || key == Rune('k') || key == Ctrl('p'):
+		return false, me.move(-1)
+	case key == KeyDown || key == Rune('j') || key == Ctrl('n'):
+		return false, me.move(1)
+	case key == KeyPageUp:
+		return false, me.move(-10)
+	case key == KeyPageDown:
+		return false, me.move(10)
+	case key == Rune('/'):
+		me.search = true
+	case key == KeyEsc:
+		if me.query.String() != "" {
+			me.query = newInput("")
+			return false, me.loadLinks()
+		}
+	case key == Rune('s'):
+		me.sort = (me.sort + 1) % len(sortOrders)
+		return false, me.loadLinks()
+	case key == Rune('S'):
+		me.reverse = !me.reverse
+		return false, me.loadLinks()
+	case key == Rune('A'):
+		me.archived = !me.archived
+		return false, me.loadLinks()
+	case key == Rune('Z'):
+		me.snoozed = !me.snoozed
+		return false, me.loadLinks()
+	case key == Rune('g'):
+		return false, me.reload()
+	default:
+		return false, me.handleLink(key)
+	}
+
+	return false, nil
+}
+
+//handleLink handles keys changing the link under cursor.
+func (me *browser) handleLink(key Key) error {
+	link := me.current()
+	if link == nil {
+		return nil
+	}
+
+	switch key {
+	case Rune('a'):
+		archive := !link.Archived
+		return me.update(link.ID, func(link *links.Link) {
+			link.Archived = archive
+			link.ArchivedAt = time.Time{}
+			if archive {
+				link.ArchivedAt = time.Now()
+			}
+		})
+	case Rune('e'):
+		me.ask("Title: ", link.Title, func(title string) error {
+			return me.update(link.ID, func(link *links.Link) {
+				link.Title = title
+			})
+		})
+	case Rune('m'):
+		me.ask("Move to list: ", "", func(list string) error {
+			return me.update(link.ID, func(link *links.Link) {
+				link.List = list
+			})
+		})
+	case Rune('r'):
+		return me.refresh(*link)
+	case Rune('o'), KeyEnter:
+		if me.Open != nil {
+			return me.Open(me.ctx, *link)
+		}
+	}
+
+	return nil
+}
+
+func (me *browser) handleSearch(key Key) error {
+	switch key {
+	case KeyEnter:
+		me.search = false
+	case KeyEsc:
+		me.search = false
+		me.query = newInput("")
+		return me.loadLinks()
+	default:
+		if me.query.handle(key) {
+			return me.loadLinks()
+		}
+	}
+
+	return nil
+}
+
+func (me *browser) handlePrompt(key Key) error {
+	prompt := me.prompt
+	switch key {
+	case KeyEsc, Ctrl('c'):
+		me.prompt = nil
+	case KeyEnter:
+		me.prompt = nil
+		if answer := strings.TrimSpace(prompt.answer.String()); answer != "" {
+			return prompt.done(answer)
+		}
+	default:
+		prompt.answer.handle(key)
+	}
+
+	return nil
+}
+
+func (me *browser) ask(label string, value string, done func(string) error) {
+	me.prompt = &browserPrompt{label: label, answer: newInput(value), done: done}
+}
+
+func (me *browser) move(delta int) error {
+	if me.focus == sidebarPane {
+		list := clamp(me.list+delta, len(me.lists))
+		if list != me.list {
+			me.list = list
+			me.cursor, me.top = 0, 0
+			return me.loadLinks()
+		}
+		return nil
+	}
+
+	me.cursor = clamp(me.cursor+delta, len(me.links))
+	return nil
+}
+
+func (me *browser) current() *links.Link {
+	if me.cursor < len(me.links) {
+		return &me.links[me.cursor]
+	}
+
+	return nil
+}
+
+//update changes the link and reloads lists and links.
+func (me *browser) update(id int, update func(*links.Link)) error {
+	filter := links.NewFilter(links.WithIDs(id), links.IncludeArchived())
+	if _, err := me.Store.UpdateLinks(me.ctx, filter, update); err != nil {
+		return fmt.Errorf("Unable to change link: %s", err)
+	}
+
+	return me.reload()
+}
+
+//refresh fetches the page of the link again and updates its title.
+func (me *browser) refresh(link links.Link) error {
+	fetch := me.Fetch
+	if fetch == nil {
+		fetch = func(ctx context.Context, url *url.URL) (*pages.Page, error) {
+			return pages.FetchPage(ctx, url)
+		}
+	}
+
+	page, err := fetch(me.ctx, link.URL)
+	if err != nil {
+		return err
+	}
+
+	if page.Title == "" {
+		return fmt.Errorf("Unable to find title of %s", link.URL)
+	}
+
+	if err := me.update(link.ID, func(link *links.Link) {
+		link.Title = page.Title
+		link.Description = page.Description
+		link.Text = page.Text
+	}); err != nil {
+		return err
+	}
+
+	me.status = fmt.Sprintf("Title: %s", page.Title)
+	return nil
+}
+
+//reload loads lists and links again keeping the cursor on the same link.
+func (me *browser) reload() error {
+	var id int
+	if link := me.current(); link != nil {
+		id = link.ID
+	}
+
+	name := me.lists[me.list]
+	if err := me.loadLists(); err != nil {
+		return err
+	}
+	me.list = me.indexOfList(name)
+
+	cursor := me.cursor
+	if err := me.loadLinks(); err != nil {
+		return err
+	}
+
+	me.cursor = clamp(cursor, len(me.links))
+	for i, link := range me.links {
+		if link.ID == id {
+			me.cursor = i
+		}
+	}
+
+	return nil
+}
+
+func (me *browser) loadLists() error {
+	all, err := me.Store.FindLinks(me.ctx, links.NewFilter(
+		links.FromList("*"),
+		links.IncludeArchived(),
+	))
+	if err != nil {
+		return fmt.Errorf("Unable to fetch lists: %s", err)
+	}
+
+	me.counts = links.NewStats(all).Lists
+	me.lists = []string{"*"}
+	for name := range me.counts {
+		me.lists = append(me.lists, name)
+	}
+
+	sort.Strings(me.lists[1:])
+	return nil
+}
+
+func (me *browser) indexOfList(name string) int {
+	for i, list := range me.lists {
+		if list == name {
+			return i
+		}
+	}
+
+	if name == "" {
+		return 0
+	}
+
+	//empty list is shown until user leaves it
+	me.lists = append(me.lists, name)
+	return len(me.lists) - 1
+}
+
+func (me *browser) loadLinks() error {
+	conds := []links.FilterCondition{links.FromList(me.lists[me.list])}
+	if me.archived {
+		conds = append(conds, links.IncludeArchived())
+	} else {
+		conds = append(conds, links.NoArchived())
+	}
+
+	if !me.snoozed {
+		conds = append(conds, links.NoSnoozed())
+	}
+
+	order := sortOrders[me.sort]
+	conds = append(conds, links.SortBy(order))
+	if me.reverse != (order == "created") {
+		conds = append(conds, links.Reverse())
+	}
+
+	filter := links.NewFilter(conds...)
+	if query := strings.TrimSpace(me.query.String()); query != "" {
+		results, err := me.Store.Search(me.ctx, query, filter)
+		if err != nil {
+			return fmt.Errorf("Unable to search links: %s", err)
+		}
+
+		me.links = make([]links.Link, 0, len(results))
+		for _, result := range results {
+			me.links = append(me.links, result.Link)
+		}
+	} else {
+		found, err := me.Store.FindLinks(me.ctx, filter)
+		if err != nil {
+			return fmt.Errorf("Unable to fetch links: %s", err)
+		}
+		me.links = found
+	}
+
+	me.cursor = clamp(me.cursor, len(me.links))
+	return nil
+}
+
+func (me *browser) view(width int, height int) []string {
+	lines := []string{me.header()}
+	rows := height - 3
+	if me.cursor < me.top {
+		me.top = me.cursor
+	} else if rows > 0 && me.cursor >= me.top+rows {
+		me.top = me.cursor - rows + 1
+	}
+
+	tableWidth := width - sidebarWidth - 1
+	lines = append(lines, fit(" Lists", sidebarWidth)+"│"+me.tableHeader(tableWidth))
+	for row := 0; row < rows; row++ {
+		lines = append(lines, me.sidebarRow(row)+"│"+me.tableRow(me.top+row, tableWidth))
+	}
+
+	return append(lines, me.footer())
+}
+
+func (me *browser) header() string {
+	list := me.lists[me.list]
+	if list == "*" {
+		list = "all"
+	}
+
+	header := fmt.Sprintf("linkman  list: %s  sort: %s", list, sortOrders[me.sort])
+	if me.reverse {
+		header += " (reversed)"
+	}
+
+	if me.archived {
+		header += "  +archived"
+	}
+
+	if me.snoozed {
+		header += "  +snoozed"
+	}
+
+	if me.search {
+		header += "  search: " + me.query.view()
+	} else if query := me.query.String(); query != "" {
+		header += "  search: " + query
+	}
+
+	return header
+}
+
+func (me *browser) sidebarRow(row int) string {
+	if row >= len(me.lists) {
+		return strings.Repeat(" ", sidebarWidth)
+	}
+
+	name := me.lists[row]
+	count := me.counts[name].Unarchived
+	if name == "*" {
+		name = "all"
+		count = 0
+		for _, counts := range me.counts {
+			count += counts.Unarchived
+		}
+	}
+
+	mark := " "
+	if row == me.list {
+		mark = "*"
+		if me.focus == sidebarPane {
+			mark = ">"
+		}
+	}
+
+	counter := fmt.Sprintf(" %d", count)
+	return mark + fit(name, sidebarWidth-1-len(counter)) + counter
+}
+
+//columns returns width of title and source columns.
+func columns(width int) (int, int) {
+	source := 12
+	title := width - 2 - 5 - 1 - source - 1 - 10 - 1
+	if title < 10 {
+		title = 10
+	}
+
+	return title, source
+}
+
+func (me *browser) tableHeader(width int) string {
+	title, source := columns(width)
+	return fmt.Sprintf("  %5s %s %s %s", "ID", fit("Title", title), fit("Source", source), "Added")
+}
+
+func (me *browser) tableRow(row int, width int) string {
+	if row >= len(me.links) {
+		return ""
+	}
+
+	link := me.links[row]
+	mark := "  "
+	if link.Archived {
+		mark = " x"
+	}
+	if row == me.cursor && me.focus == tablePane {
+		mark = ">" + mark[1:]
+	}
+
+	text := link.Title
+	if text == "" {
+		text = link.URL.String()
+	}
+
+	title, source := columns(width)
+	return fmt.Sprintf("%s%5d %s %s %s", mark, link.ID, fit(text, title),
+		fit(link.Source, source), link.Created.Format("2006-01-02"))
+}
+
+func (me *browser) footer() string {
+	switch {
+	case me.prompt != nil:
+		return me.prompt.label + me.prompt.answer.view()
+	case me.status != "":
+		return me.status
+	case me.search:
+		return "type to search  enter done  esc clear"
+	}
+
+	help := "tab pane  / search  s sort  S reverse  A archived  Z snoozed  a archive  e title  m move  r refresh"
+	if me.Open != nil {
+		help += "  o open"
+	}
+
+	return help + "  q quit"
+}
+
+func clamp(value int, length int) int {
+	if value >= length {
+		value = length - 1
+	}
+
+	if value < 0 {
+		value = 0
+	}
+
+	return value
+}
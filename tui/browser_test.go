@@ -0,0 +1,175 @@
+package tui_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dikeert/linkman/links"
+	"github.com/dikeert/linkman/pages"
+	"github.com/dikeert/linkman/tui"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrowser(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	tests := []struct {
+		name  string
+		keys  []tui.Key
+		check func(*assert.Assertions, *tui.Screen, links.Store)
+	}{
+		{"ShowsListsAndLinks", nil, func(assert *assert.Assertions, screen *tui.Screen, store links.Store) {
+			lines := screen.Lines()
+			assert.Equal("linkman  list: reading  sort: created", lines[0])
+			assert.Equal(" all 3", sidebar(lines[2]))
+			assert.Equal(" default 1", sidebar(lines[3]))
+			assert.Equal("*reading 2", sidebar(lines[4]))
+			assert.Equal(">     2 Rust book                   doc          "+today, lines[2][sidebarEnd:])
+			assert.Equal("      1 Bolt transactions           github       "+today, lines[3][sidebarEnd:])
+		}},
+		{"SwitchesLists", []tui.Key{tui.KeyLeft, tui.KeyUp}, func(assert *assert.Assertions, screen *tui.Screen, store links.Store) {
+			lines := screen.Lines()
+			assert.Equal("linkman  list: default  sort: created", lines[0])
+			assert.Equal(">default 1", sidebar(lines[3]))
+			assert.Contains(lines[2], "      3 Go blog")
+		}},
+		{"SearchesLive", append(append([]tui.Key{tui.Rune('/')}, tui.Type("bolt")...), tui.KeyEnter), func(assert *assert.Assertions, screen *tui.Screen, store links.Store) {
+			lines := screen.Lines()
+			assert.Equal("linkman  list: reading  sort: created  search: bolt", lines[0])
+			assert.Contains(lines[2], ">     1 Bolt transactions")
+			assert.NotContains(screen.String(), "Rust book")
+		}},
+		{"SortsAndArchives", []tui.Key{tui.Rune('s'), tui.Rune('a'), tui.Rune('A')}, func(assert *assert.Assertions, screen *tui.Screen, store links.Store) {
+			lines := screen.Lines()
+			assert.Equal("linkman  list: reading  sort: title  +archived", lines[0])
+			assert.Contains(lines[2], ">x    1 Bolt transactions")
+			assert.Equal("*reading 1", sidebar(lines[4]))
+			assert.True(find(store, 1).Archived, "Should archive link")
+		}},
+		{"UnarchivesLink", []tui.Key{tui.Rune('A'), tui.Rune('a'), tui.Rune('a')}, func(assert *assert.Assertions, screen *tui.Screen, store links.Store) {
+			assert.False(find(store, 2).Archived, "Should unarchive link")
+		}},
+		{"EditsTitle", append(append([]tui.Key{tui.Rune('e'), tui.Ctrl('u')}, tui.Type("The book")...), tui.KeyEnter),
+			func(assert *assert.Assertions, screen *tui.Screen, store links.Store) {
+				assert.Equal("The book", find(store, 2).Title)
+				assert.Contains(screen.Lines()[2], ">     2 The book")
+			}},
+		{"MovesLink", append(append([]tui.Key{tui.Rune('m')}, tui.Type("watch")...), tui.KeyEnter),
+			func(assert *assert.Assertions, screen *tui.Screen, store links.Store) {
+				assert.Equal("watch", find(store, 2).List)
+				assert.Equal(" watch 1", sidebar(screen.Lines()[5]))
+				assert.Contains(screen.Lines()[2], ">     1 Bolt transactions")
+			}},
+		{"RefreshesTitle", []tui.Key{tui.Rune('r')}, func(assert *assert.Assertions, screen *tui.Screen, store links.Store) {
+			assert.Equal("Fetched title", find(store, 2).Title)
+			assert.Equal("Title: Fetched title", screen.Lines()[len(screen.Lines())-1])
+		}},
+		{"KeepsPromptCancelled", []tui.Key{tui.Rune('e'), tui.KeyEsc}, func(assert *assert.Assertions, screen *tui.Screen, store links.Store) {
+			assert.Equal("Rust book", find(store, 2).Title)
+		}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			store, path := newStore(t)
+			defer os.Remove(path)
+
+			screen := tui.NewScreen(80, 8, append(tc.keys, tui.Rune('q'))...)
+			browser := &tui.Browser{
+				Store: store,
+				List:  "reading",
+				Fetch: func(ctx context.Context, url *url.URL) (*pages.Page, error) {
+					return &pages.Page{Title: "Fetched title"}, nil
+				},
+			}
+
+			if err := browser.Run(context.Background(), screen); err != nil {
+				t.Fatal(err)
+			}
+
+			tc.check(assert.New(t), screen, store)
+		})
+	}
+}
+
+func TestBrowserStopsWhenCancelled(t *testing.T) {
+	store, path := newStore(t)
+	defer os.Remove(path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := (&tui.Browser{Store: store}).Run(ctx, tui.NewScreen(80, 8, tui.Rune('j')))
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestBrowserHidesSnoozed(t *testing.T) {
+	assert := assert.New(t)
+	store, path := newStore(t)
+	defer os.Remove(path)
+
+	_, err := store.UpdateLinks(context.Background(), links.NewFilter(links.WithIDs(2)), func(link *links.Link) {
+		link.Snooze(time.Now().Add(time.Hour), false)
+	})
+	assert.NoError(err)
+
+	screen := tui.NewScreen(80, 8, tui.Rune('q'))
+	assert.NoError((&tui.Browser{Store: store, List: "reading"}).Run(context.Background(), screen))
+	assert.NotContains(screen.String(), "Rust book", "Should hide snoozed link")
+
+	screen = tui.NewScreen(80, 8, tui.Rune('Z'), tui.Rune('q'))
+	assert.NoError((&tui.Browser{Store: store, List: "reading"}).Run(context.Background(), screen))
+	assert.Equal("linkman  list: reading  sort: created  +snoozed", screen.Lines()[0])
+	assert.Contains(screen.String(), "Rust book", "Should show snoozed link")
+}
+
+func newStore(t *testing.T) (links.Store, string) {
+	file, err := ioutil.TempFile("", "linkman.*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	ctx := context.Background()
+	store, err := links.OpenStore(ctx, file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, link := range []struct{ url, title, list string }{
+		{"https://github.com/boltdb", "Bolt transactions", "reading"},
+		{"https://doc.rust-lang.org/book", "Rust book", "reading"},
+		{"https://go.dev/blog", "Go blog", "default"},
+	} {
+		parsed, _ := url.Parse(link.url)
+		source := strings.Split(parsed.Hostname(), ".")[0]
+		if err := store.SaveLink(ctx, store.NewLink(parsed, source, link.title, link.list)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return store, file.Name()
+}
+
+func find(store links.Store, id int) links.Link {
+	found, _ := store.FindLinks(context.Background(), links.NewFilter(
+		links.WithIDs(id),
+		links.IncludeArchived(),
+	))
+
+	return found[0]
+}
+
+//sidebarEnd is where the sidebar of the browser ends, after its border.
+var sidebarEnd = 20 + len("│")
+
+//sidebar returns mark, name and count of the list shown in the line.
+func sidebar(line string) string {
+	return line[:1] + strings.Join(strings.Fields(line[1:sidebarEnd-len("│")]), " ")
+}
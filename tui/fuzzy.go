@@ -0,0 +1,30 @@
+package tui
+
+import (
+	"sort"
+
+	"github.com/dikeert/linkman/links"
+)
+
+//match is an item matching the query.
+type match struct {
+	index int
+	score int
+}
+
+//fuzzyFilter returns items matching the query, best matches first,
+//items with the same score keep their order.
+func fuzzyFilter(query []rune, items []string) []match {
+	matches := make([]match, 0, len(items))
+	for i, item := range items {
+		if score, ok := links.FuzzyScore(string(query), item); ok {
+			matches = append(matches, match{index: i, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	return matches
+}
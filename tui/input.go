@@ -0,0 +1,74 @@
+package tui
+
+//input is a single line text field.
+type input struct {
+	text   []rune
+	cursor int
+}
+
+func newInput(text string) *input {
+	runes := []rune(text)
+	return &input{text: runes, cursor: len(runes)}
+}
+
+func (me *input) String() string {
+	return string(me.text)
+}
+
+//handle edits the text according to the key and
+//tells whether the key is an editing one.
+func (me *input) handle(key Key) bool {
+	switch {
+	case key.IsRune():
+		me.text = append(me.text[:me.cursor], append([]rune{key.Rune}, me.text[me.cursor:]...)...)
+		me.cursor++
+	case key == KeyBackspace || key == Ctrl('h'):
+		if me.cursor > 0 {
+			me.text = append(me.text[:me.cursor-1], me.text[me.cursor:]...)
+			me.cursor--
+		}
+	case key == KeyDelete:
+		if me.cursor < len(me.text) {
+			me.text = append(me.text[:me.cursor], me.text[me.cursor+1:]...)
+		}
+	case key == KeyLeft || key == Ctrl('b'):
+		if me.cursor > 0 {
+			me.cursor--
+		}
+	case key == KeyRight || key == Ctrl('f'):
+		if me.cursor < len(me.text) {
+			me.cursor++
+		}
+	case key == KeyHome:
+		me.cursor = 0
+	case key == KeyEnd || key == Ctrl('e'):
+		me.cursor = len(me.text)
+	case key == Ctrl('u'):
+		me.text = me.text[me.cursor:]
+		me.cursor = 0
+	case key == Ctrl('w'):
+		start := me.cursor
+		for start > 0 && me.text[start-1] == ' ' {
+			start--
+		}
+		for start > 0 && me.text[start-1] != ' ' {
+			start--
+		}
+		me.text = append(me.text[:start], me.text[me.cursor:]...)
+		me.cursor = start
+	default:
+		return false
+	}
+
+	return true
+}
+
+//view renders the text with cursor shown as '_' at its end
+//or as '|' inside.
+func (me *input) view() string {
+	if me.cursor == len(me.text) {
+		return string(me.text) + "_"
+	}
+
+	return string(me.text[:me.cursor]) + "|" + string(me.text[me.cursor:])
+}
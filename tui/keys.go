@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+//Key is a key pressed by user, either a printable
+//rune or a named key such as "enter" or "ctrl-o".
+type Key struct {
+	Name string
+	Rune rune
+}
+
+//Named keys.
+var (
+	KeyEnter     = Key{Name: "enter"}
+	KeyEsc       = Key{Name: "esc"}
+	KeyTab       = Key{Name: "tab"}
+	KeyBackspace = Key{Name: "backspace"}
+	KeyDelete    = Key{Name: "delete"}
+	KeyUp        = Key{Name: "up"}
+	KeyDown      = Key{Name: "down"}
+	KeyLeft      = Key{Name: "left"}
+	KeyRight     = Key{Name: "right"}
+	KeyHome      = Key{Name: "home"}
+	KeyEnd       = Key{Name: "end"}
+	KeyPageUp    = Key{Name: "pgup"}
+	KeyPageDown  = Key{Name: "pgdown"}
+)
+
+var escapes = map[string]Key{
+	"[A": KeyUp, "[B": KeyDown, "[C": KeyRight, "[D": KeyLeft,
+	"OA": KeyUp, "OB": KeyDown, "OC": KeyRight, "OD": KeyLeft,
+	"[H": KeyHome, "[F": KeyEnd, "OH": KeyHome, "OF": KeyEnd,
+	"[1~": KeyHome, "[4~": KeyEnd, "[7~": KeyHome, "[8~": KeyEnd,
+	"[3~": KeyDelete, "[5~": KeyPageUp, "[6~": KeyPageDown,
+	"[Z": Key{Name: "shift-tab"},
+}
+
+//Rune returns key for printable rune.
+func Rune(r rune) Key {
+	return Key{Rune: r}
+}
+
+//Ctrl returns key for the letter pressed with Ctrl, e.g. Ctrl('o').
+func Ctrl(letter rune) Key {
+	return Key{Name: "ctrl-" + string(letter)}
+}
+
+//Type returns keys typing the text.
+func Type(text string) []Key {
+	var keys []Key
+	for _, r := range text {
+		keys = append(keys, Rune(r))
+	}
+
+	return keys
+}
+
+//IsRune tells whether the key is a printable rune.
+func (me Key) IsRune() bool {
+	return me.Name == "" && me.Rune != 0
+}
+
+func (me Key) String() string {
+	if me.IsRune() {
+		return string(me.Rune)
+	}
+
+	return me.Name
+}
+
+//Label returns short description of the key for help lines,
+//e.g. ^O for ctrl-o.
+func (me Key) Label() string {
+	if strings.HasPrefix(me.Name, "ctrl-") {
+		return "^" + strings.ToUpper(strings.TrimPrefix(me.Name, "ctrl-"))
+	}
+
+	return me.String()
+}
+
+//ReadKey reads single key from the input of terminal in raw mode.
+func ReadKey(reader *bufio.Reader) (Key, error) {
+	b, err := reader.ReadByte()
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch {
+	case b == 27:
+		return readEscape(reader)
+	case b == '\r' || b == '\n':
+		return KeyEnter, nil
+	case b == '\t':
+		return KeyTab, nil
+	case b == 127 || b == 8:
+		return KeyBackspace, nil
+	case b >= 1 && b <= 26:
+		return Ctrl(rune('a' + b - 1)), nil
+	case b < utf8.RuneSelf:
+		return Rune(rune(b)), nil
+	}
+
+	reader.UnreadByte()
+	r, _, err := reader.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Rune(r), nil
+}
+
+//readEscape tells Esc apart from escape sequence of a special key,
+//the sequence arrives at once, so Esc is followed by nothing.
+func readEscape(reader *bufio.Reader) (Key, error) {
+	if reader.Buffered() == 0 {
+		return KeyEsc, nil
+	}
+
+	var sequence strings.Builder
+	for reader.Buffered() > 0 && sequence.Len() < 8 {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return Key{}, err
+		}
+
+		sequence.WriteByte(b)
+		if key, ok := escapes[sequence.String()]; ok {
+			return key, nil
+		}
+
+		if sequence.Len() > 1 && (b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b == '~') {
+			break
+		}
+	}
+
+	return Key{Name: fmt.Sprintf("esc%s", sequence.String())}, nil
+}
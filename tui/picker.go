@@ -0,0 +1,238 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Picker lets user choose items by typing fuzzy query, like fzf does.
+type Picker struct {
+	//Prompt is shown in front of the query.
+	Prompt string
+	//Items are lines to choose from.
+	Items []string
+	//Multi allows to select several items with Tab.
+	Multi bool
+	//Bindings are actions available besides Enter.
+	Bindings []Binding
+}
+
+//Binding binds a key to an action of the picker.
+type Binding struct {
+	Key    Key
+	Action string
+	//Ask is a question asked before the action is chosen,
+	//answer is returned as Choice.Input, e.g. name of a list.
+	Ask string
+}
+
+//Choice is what user has chosen.
+type Choice struct {
+	//Action is the action of the binding, empty for Enter.
+	Action string
+	//Items are indexes of chosen items in Picker.Items.
+	Items []int
+	//Input is the answer to the question of the binding.
+	Input string
+}
+
+type picker struct {
+	*Picker
+	query    *input
+	matches  []match
+	cursor   int
+	top      int
+	selected map[int]bool
+	asking   *Binding
+	answer   *input
+}
+
+//Run shows the picker until user chooses items or leaves it,
+//which returns ErrCancelled.
+func (me *Picker) Run(term Terminal) (*Choice, error) {
+	state := &picker{
+		Picker:   me,
+		query:    newInput(""),
+		selected: map[int]bool{},
+	}
+	state.filter()
+
+	for {
+		if err := term.Draw(state.view(term.Size())); err != nil {
+			return nil, err
+		}
+
+		key, err := term.ReadKey()
+		if err != nil {
+			return nil, err
+		}
+
+		choice, err := state.handle(key)
+		if choice != nil || err != nil {
+			return choice, err
+		}
+	}
+}
+
+func (me *picker) handle(key Key) (*Choice, error) {
+	if me.asking != nil {
+		return me.handleAnswer(key)
+	}
+
+	switch {
+	case key == KeyEsc || key == Ctrl('c') || key == Ctrl('g'):
+		return nil, ErrCancelled
+	case key == KeyEnter:
+		return me.choose(&Binding{}), nil
+	case key == KeyUp || key == Ctrl('p') || key == Ctrl('k'):
+		me.move(-1)
+	case key == KeyDown || key == Ctrl('n') || key == Ctrl('j'):
+		me.move(1)
+	case key == KeyPageUp:
+		me.move(-10)
+	case key == KeyPageDown:
+		me.move(10)
+	case key == KeyTab && me.Multi:
+		if len(me.matches) > 0 {
+			index := me.matches[me.cursor].index
+			me.selected[index] = !me.selected[index]
+			me.move(1)
+		}
+	case me.query.handle(key):
+		me.filter()
+	default:
+		for i := range me.Bindings {
+			if me.Bindings[i].Key == key {
+				return me.choose(&me.Bindings[i]), nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (me *picker) handleAnswer(key Key) (*Choice, error) {
+	switch {
+	case key == KeyEsc || key == Ctrl('c') || key == Ctrl('g'):
+		me.asking = nil
+	case key == KeyEnter:
+		if answer := strings.TrimSpace(me.answer.String()); answer != "" {
+			choice := me.chosen(me.asking.Action)
+			choice.Input = answer
+			return choice, nil
+		}
+	default:
+		me.answer.handle(key)
+	}
+
+	return nil, nil
+}
+
+//choose returns the choice for the binding or
+//asks the question of the binding first.
+func (me *picker) choose(binding *Binding) *Choice {
+	if len(me.matches) == 0 {
+		return nil
+	}
+
+	if binding.Ask != "" {
+		me.asking = binding
+		me.answer = newInput("")
+		return nil
+	}
+
+	return me.chosen(binding.Action)
+}
+
+//chosen returns selected items or the one under cursor.
+func (me *picker) chosen(action string) *Choice {
+	choice := &Choice{Action: action}
+	for i := range me.Items {
+		if me.selected[i] {
+			choice.Items = append(choice.Items, i)
+		}
+	}
+
+	if len(choice.Items) == 0 {
+		choice.Items = []int{me.matches[me.cursor].index}
+	}
+
+	return choice
+}
+
+func (me *picker) filter() {
+	me.matches = fuzzyFilter(me.query.text, me.Items)
+	me.cursor, me.top = 0, 0
+}
+
+func (me *picker) move(delta int) {
+	me.cursor += delta
+	if me.cursor >= len(me.matches) {
+		me.cursor = len(me.matches) - 1
+	}
+
+	if me.cursor < 0 {
+		me.cursor = 0
+	}
+}
+
+func (me *picker) view(width int, height int) []string {
+	var lines []string
+	if me.asking != nil {
+		lines = append(lines, me.asking.Ask+me.answer.view())
+	} else {
+		lines = append(lines, me.Prompt+me.query.view())
+	}
+
+	status := fmt.Sprintf("  %d/%d", len(me.matches), len(me.Items))
+	if count := me.selectedCount(); count > 0 {
+		status += fmt.Sprintf(" (%d selected)", count)
+	}
+	lines = append(lines, status+"  "+me.help())
+
+	rows := height - len(lines)
+	if me.cursor < me.top {
+		me.top = me.cursor
+	} else if rows > 0 && me.cursor >= me.top+rows {
+		me.top = me.cursor - rows + 1
+	}
+
+	for i := me.top; i < len(me.matches) && i < me.top+rows; i++ {
+		index := me.matches[i].index
+		mark := "  "
+		if me.selected[index] {
+			mark = " *"
+		}
+		if i == me.cursor {
+			mark = ">" + mark[1:]
+		}
+
+		lines = append(lines, fit(mark+" "+me.Items[index], width))
+	}
+
+	return lines
+}
+
+func (me *picker) selectedCount() int {
+	count := 0
+	for _, selected := range me.selected {
+		if selected {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (me *picker) help() string {
+	help := []string{"enter choose"}
+	if me.Multi {
+		help = append(help, "tab select")
+	}
+
+	for _, binding := range me.Bindings {
+		help = append(help, binding.Key.Label()+" "+binding.Action)
+	}
+
+	return strings.Join(append(help, "esc quit"), "  ")
+}
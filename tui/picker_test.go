@@ -0,0 +1,105 @@
+package tui_test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/dikeert/linkman/tui"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var items = []string{
+	"Bolt transactions  [github] (reading)",
+	"Go blog  [go] (default)",
+	"Rust book  [rust-lang] (reading)",
+}
+
+func TestPickerFilters(t *testing.T) {
+	assert := assert.New(t)
+	screen := tui.NewScreen(40, 10, tui.Type("blttr")...)
+	picker := &tui.Picker{Prompt: "> ", Items: items}
+
+	_, err := picker.Run(screen)
+	assert.Error(err, "Should stop when keys run out")
+	assert.Equal([]string{
+		"> blttr_",
+		"  1/3  enter choose  esc quit",
+		">  Bolt transactions  [github] (reading)",
+	}, screen.Lines())
+}
+
+func TestPickerChoosesItem(t *testing.T) {
+	assert := assert.New(t)
+	keys := append(tui.Type("o"), tui.KeyDown, tui.KeyEnter)
+	picker := &tui.Picker{Items: items}
+
+	choice, err := picker.Run(tui.NewScreen(40, 10, keys...))
+	if assert.NoError(err) {
+		assert.Equal("", choice.Action)
+		assert.Equal([]int{1}, choice.Items, "Should choose second best match")
+	}
+}
+
+func TestPickerSelectsMany(t *testing.T) {
+	assert := assert.New(t)
+	screen := tui.NewScreen(80, 10, tui.KeyTab, tui.KeyDown, tui.KeyTab, tui.Ctrl('a'))
+	picker := &tui.Picker{
+		Items:    items,
+		Multi:    true,
+		Bindings: []tui.Binding{{Key: tui.Ctrl('a'), Action: "archive"}},
+	}
+
+	choice, err := picker.Run(screen)
+	if assert.NoError(err) {
+		assert.Equal("archive", choice.Action)
+		assert.Equal([]int{0, 2}, choice.Items)
+	}
+
+	assert.Equal("  3/3 (2 selected)  enter choose  tab select  ^A archive  esc quit",
+		screen.Lines()[1])
+	assert.Equal(" * Bolt transactions  [github] (reading)", screen.Lines()[2])
+}
+
+func TestPickerAsks(t *testing.T) {
+	assert := assert.New(t)
+	keys := append([]tui.Key{tui.Ctrl('t')}, tui.Type("watch")...)
+	screen := tui.NewScreen(40, 10, append(keys, tui.KeyEnter)...)
+	picker := &tui.Picker{
+		Items:    items,
+		Bindings: []tui.Binding{{Key: tui.Ctrl('t'), Action: "move", Ask: "Move to: "}},
+	}
+
+	choice, err := picker.Run(screen)
+	if assert.NoError(err) {
+		assert.Equal("move", choice.Action)
+		assert.Equal("watch", choice.Input)
+		assert.Equal([]int{0}, choice.Items)
+	}
+
+	assert.Equal("Move to: watch_", screen.Lines()[0])
+}
+
+func TestPickerCancels(t *testing.T) {
+	picker := &tui.Picker{Items: items}
+	_, err := picker.Run(tui.NewScreen(40, 10, tui.KeyEsc))
+	assert.Equal(t, tui.ErrCancelled, err)
+}
+
+func TestReadKey(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("a\x1b[A\r\x0f\x7fж\t"))
+	var keys []tui.Key
+	for {
+		key, err := tui.ReadKey(reader)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+	}
+
+	assert.Equal(t, []tui.Key{
+		tui.Rune('a'), tui.KeyUp, tui.KeyEnter, tui.Ctrl('o'),
+		tui.KeyBackspace, tui.Rune('ж'), tui.KeyTab,
+	}, keys)
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+//Screen is simulated Terminal: keys are given in advance and
+//drawn frames are kept, so views can be tested without TTY.
+type Screen struct {
+	mutex  sync.Mutex
+	width  int
+	height int
+	keys   []Key
+	frames [][]string
+}
+
+//NewScreen creates screen of the size which replays the keys,
+//ReadKey returns io.EOF once they run out.
+func NewScreen(width int, height int, keys ...Key) *Screen {
+	return &Screen{width: width, height: height, keys: keys}
+}
+
+//Press adds keys to be replayed.
+func (me *Screen) Press(keys ...Key) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.keys = append(me.keys, keys...)
+}
+
+//ReadKey returns the next of given keys.
+func (me *Screen) ReadKey() (Key, error) {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if len(me.keys) == 0 {
+		return Key{}, io.EOF
+	}
+
+	key := me.keys[0]
+	me.keys = me.keys[1:]
+	return key, nil
+}
+
+//Size returns size the screen is created with.
+func (me *Screen) Size() (int, int) {
+	return me.width, me.height
+}
+
+//Draw keeps the frame, lines are cut to the size of the screen.
+func (me *Screen) Draw(lines []string) error {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	frame := make([]string, 0, me.height)
+	for i := 0; i < len(lines) && i < me.height; i++ {
+		frame = append(frame, strings.TrimRight(cut(lines[i], me.width), " "))
+	}
+
+	me.frames = append(me.frames, frame)
+	return nil
+}
+
+//Lines returns the last drawn frame.
+func (me *Screen) Lines() []string {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if len(me.frames) == 0 {
+		return nil
+	}
+
+	return me.frames[len(me.frames)-1]
+}
+
+//String returns the last drawn frame as text.
+func (me *Screen) String() string {
+	return strings.Join(me.Lines(), "\n")
+}
+
+//Frames returns number of drawn frames.
+func (me *Screen) Frames() int {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	return len(me.frames)
+}
@@ -0,0 +1,47 @@
+//Package tui implements interactive terminal views of linkman:
+//fuzzy picker and links browser. Views draw whole frames on
+//a Terminal, which is either a real TTY or simulated Screen.
+package tui
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+//ErrCancelled tells that user left the view without choosing anything.
+var ErrCancelled = errors.New("cancelled")
+
+//Terminal is where views are drawn and keys come from.
+type Terminal interface {
+	//ReadKey waits for the next key.
+	ReadKey() (Key, error)
+	//Size returns number of columns and rows.
+	Size() (width int, height int)
+	//Draw replaces contents of the terminal with the lines.
+	Draw(lines []string) error
+}
+
+//fit cuts or pads text to exactly width columns.
+func fit(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	length := utf8.RuneCountInString(text)
+	if length > width {
+		runes := []rune(text)
+		return string(runes[:width-1]) + "…"
+	}
+
+	return text + strings.Repeat(" ", width-length)
+}
+
+//cut cuts text down to width columns.
+func cut(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+
+	return strings.TrimRight(fit(text, width), " ")
+}
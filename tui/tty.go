@@ -0,0 +1,140 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package tui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+)
+
+//TTY is Terminal of the controlling terminal, so views work
+//while standard output is redirected.
+type TTY struct {
+	file   *os.File
+	reader *bufio.Reader
+	state  *unix.Termios
+	close  sync.Once
+}
+
+//OpenTTY switches controlling terminal into raw mode and
+//alternate screen, TTY is closed when ctx is done.
+func OpenTTY(ctx context.Context) (*TTY, error) {
+	file, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open terminal: %s", err)
+	}
+
+	var state *unix.Termios
+	err = control(file, func(fd int) (err error) {
+		if state, err = unix.IoctlGetTermios(fd, ioctlReadTermios); err != nil {
+			return err
+		}
+
+		raw := *state
+		raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP |
+			unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+		raw.Oflag &^= unix.OPOST
+		raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+		raw.Cflag &^= unix.CSIZE | unix.PARENB
+		raw.Cflag |= unix.CS8
+		raw.Cc[unix.VMIN] = 1
+		raw.Cc[unix.VTIME] = 0
+		return unix.IoctlSetTermios(fd, ioctlWriteTermios, &raw)
+	})
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("Unable to open terminal: %s", err)
+	}
+
+	me := &TTY{file: file, reader: bufio.NewReader(file), state: state}
+	file.WriteString(enterScreen)
+
+	go func() {
+		<-ctx.Done()
+		me.Close()
+	}()
+
+	return me, nil
+}
+
+//ReadKey waits for the next key.
+func (me *TTY) ReadKey() (Key, error) {
+	return ReadKey(me.reader)
+}
+
+//Size returns number of columns and rows of the terminal.
+func (me *TTY) Size() (int, int) {
+	var size *unix.Winsize
+	err := control(me.file, func(fd int) (err error) {
+		size, err = unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+		return err
+	})
+
+	if err != nil || size.Col == 0 || size.Row == 0 {
+		return 80, 24
+	}
+
+	return int(size.Col), int(size.Row)
+}
+
+//Draw replaces contents of the terminal with the lines.
+func (me *TTY) Draw(lines []string) error {
+	width, height := me.Size()
+	var frame strings.Builder
+	frame.WriteString("\x1b[H")
+	for i := 0; i < len(lines) && i < height; i++ {
+		if i > 0 {
+			frame.WriteString("\r\n")
+		}
+		frame.WriteString(cut(lines[i], width))
+		frame.WriteString("\x1b[K")
+	}
+	frame.WriteString("\x1b[J")
+
+	_, err := me.file.WriteString(frame.String())
+	return err
+}
+
+//Close brings the terminal back into the state it was in,
+//pending ReadKey returns error.
+func (me *TTY) Close() error {
+	err := os.ErrClosed
+	me.close.Do(func() {
+		me.file.WriteString(leaveScreen)
+		control(me.file, func(fd int) error {
+			return unix.IoctlSetTermios(fd, ioctlWriteTermios, me.state)
+		})
+		err = me.file.Close()
+	})
+
+	return err
+}
+
+//control runs f with descriptor of the file, unlike Fd
+//it keeps the file non-blocking, so Close interrupts reading.
+func control(file *os.File, f func(fd int) error) error {
+	conn, err := file.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var ferr error
+	if err := conn.Control(func(fd uintptr) { ferr = f(int(fd)) }); err != nil {
+		return err
+	}
+
+	return ferr
+}
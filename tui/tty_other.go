@@ -0,0 +1,25 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package tui
+
+import (
+	"context"
+	"errors"
+)
+
+//TTY is Terminal of the controlling terminal, it isn't
+//supported on this platform.
+type TTY struct {
+	Terminal
+}
+
+//OpenTTY reports that terminal isn't supported.
+func OpenTTY(ctx context.Context) (*TTY, error) {
+	return nil, errors.New("Unable to open terminal: not supported on this platform")
+}
+
+//Close does nothing.
+func (me *TTY) Close() error {
+	return nil
+}
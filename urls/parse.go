@@ -3,26 +3,22 @@ package urls
 import (
 	"fmt"
 	"net/url"
-
-	tld "golang.org/x/net/publicsuffix"
 )
 
-//GetSource calculates Source value for provided url.
-//Source value is second (or third level) domain name.
+//GetSource calculates Source value for provided url by default
+//source rules. Source value is second (or third level) domain name,
+//IPs and hosts without public suffix are their own sources.
 //For example:
 //| link              | source        |
 //| ----              | ------        |
 //| youtube.com       | youtube       |
+//| youtu.be          | youtube       |
 //| stackoverflow.com | stackoverflow |
 //| domain.co.uk      | domain        |
+//| localhost:8080    | localhost     |
+//| 192.168.1.1       | 192.168.1.1   |
 func GetSource(url *url.URL) (string, error) {
-	msg := "Unable to create source string: %"
-
-	if err := validate(url); err != nil {
-		return "", fmt.Errorf(msg, err)
-	}
-
-	return extractSource(url.Hostname(), msg)
+	return defaultSources.Source(url)
 }
 
 //ParseURL parses provided rawurl string into actual URL object.
@@ -35,16 +31,6 @@ func ParseURL(rawurl string) (*url.URL, error) {
 	return url, validate(url)
 }
 
-func extractSource(hostname string, errMsg string) (string, error) {
-	tldPlusOne, err := tld.EffectiveTLDPlusOne(hostname)
-	if err != nil {
-		return "", fmt.Errorf(errMsg, err)
-	}
-
-	suffix, _ := tld.PublicSuffix(tldPlusOne)
-	return tldPlusOne[:len(tldPlusOne)-len(suffix)-1], nil
-}
-
 func validate(subjectURL *url.URL) error {
 	var err error
 	validators := []func(*url.URL) error{
@@ -0,0 +1,199 @@
+package urls
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	tld "golang.org/x/net/publicsuffix"
+)
+
+//SourceRule calculates source of URLs matching its Pattern. Pattern
+//is host optionally followed by path, e.g. "github.com/{owner}" or
+//"{blog}.medium.com", where {name} matches a whole label of the host
+//or a segment of the path and www. of the host is ignored. Source
+//is a template of the source, e.g. "{blog}", when it's empty the
+//source is the usual one followed by matched values, "github/golang".
+//Patterns without {name} make aliases, e.g. "youtu.be" of "youtube".
+type SourceRule struct {
+	Pattern string
+	Source  string
+}
+
+//DefaultSourceRules are checked after rules given to NewSources.
+var DefaultSourceRules = []SourceRule{
+	{Pattern: "youtu.be", Source: "youtube"},
+}
+
+//Sources calculates sources of URLs by rules falling back to the
+//second (or third) level domain name, nil Sources uses default rules.
+type Sources struct {
+	rules []*sourceRule
+}
+
+type sourceRule struct {
+	SourceRule
+	host  []string
+	path  []string
+	names []string
+}
+
+var placeholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+var defaultSources, _ = NewSources()
+
+//NewSources checks rules and prepares them for matching,
+//the first matching rule calculates the source.
+func NewSources(rules ...SourceRule) (*Sources, error) {
+	me := &Sources{}
+	for _, rule := range append(rules, DefaultSourceRules...) {
+		compiled, err := compileSourceRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid source rule %q: %s", rule.Pattern, err)
+		}
+
+		me.rules = append(me.rules, compiled)
+	}
+
+	return me, nil
+}
+
+func compileSourceRule(rule SourceRule) (*sourceRule, error) {
+	pattern := strings.ToLower(strings.TrimSpace(rule.Pattern))
+	parts := strings.SplitN(pattern, "/", 2)
+	me := &sourceRule{
+		SourceRule: rule,
+		host:       strings.Split(strings.TrimPrefix(parts[0], "www."), "."),
+	}
+
+	if parts[0] == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+
+	if len(parts) > 1 && strings.Trim(parts[1], "/") != "" {
+		me.path = strings.Split(strings.Trim(parts[1], "/"), "/")
+	}
+
+	for _, part := range append(append([]string{}, me.host...), me.path...) {
+		if name, ok := placeholderName(part); ok {
+			if name == "" {
+				return nil, fmt.Errorf("empty {}")
+			}
+			me.names = append(me.names, name)
+		} else if strings.ContainsAny(part, "{}") {
+			return nil, fmt.Errorf("{name} should be a whole label or segment")
+		}
+	}
+
+	for _, match := range placeholder.FindAllStringSubmatch(strings.ToLower(rule.Source), -1) {
+		if !me.has(match[1]) {
+			return nil, fmt.Errorf("source uses unknown {%s}", match[1])
+		}
+	}
+
+	return me, nil
+}
+
+func placeholderName(part string) (string, bool) {
+	if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+		return part[1 : len(part)-1], true
+	}
+
+	return "", false
+}
+
+func (me *sourceRule) has(name string) bool {
+	for _, known := range me.names {
+		if known == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+//match returns values of {name} parts when the rule matches
+//the host and path.
+func (me *sourceRule) match(host []string, path []string) ([]string, bool) {
+	if len(host) != len(me.host) || len(path) < len(me.path) {
+		return nil, false
+	}
+
+	var values []string
+	actual := append(append([]string{}, host...), path[:len(me.path)]...)
+	for i, part := range append(append([]string{}, me.host...), me.path...) {
+		value := actual[i]
+		if _, ok := placeholderName(part); ok {
+			values = append(values, value)
+		} else if part != value {
+			return nil, false
+		}
+	}
+
+	return values, true
+}
+
+//Source calculates source of the URL.
+func (me *Sources) Source(url *url.URL) (string, error) {
+	if me == nil {
+		me = defaultSources
+	}
+
+	if err := validate(url); err != nil {
+		return "", fmt.Errorf("Unable to create source string: %s", err)
+	}
+
+	hostname := strings.ToLower(url.Hostname())
+	host := strings.Split(strings.TrimPrefix(hostname, "www."), ".")
+	var path []string
+	if trimmed := strings.Trim(url.Path, "/"); trimmed != "" {
+		path = strings.Split(strings.ToLower(trimmed), "/")
+	}
+
+	for _, rule := range me.rules {
+		values, ok := rule.match(host, path)
+		if !ok {
+			continue
+		}
+
+		if rule.Source != "" {
+			return rule.expand(values), nil
+		}
+
+		return strings.Join(append([]string{extractSource(hostname)}, values...), "/"), nil
+	}
+
+	return extractSource(hostname), nil
+}
+
+func (me *sourceRule) expand(values []string) string {
+	return placeholder.ReplaceAllStringFunc(strings.ToLower(me.Source), func(match string) string {
+		name, _ := placeholderName(match)
+		for i, known := range me.names {
+			if known == name {
+				return values[i]
+			}
+		}
+
+		return match
+	})
+}
+
+//extractSource returns second (or third) level domain name of the
+//host, IPs and hosts without public suffix, such as localhost or
+//intranet ones, are their own sources.
+func extractSource(hostname string) string {
+	if ip := net.ParseIP(hostname); ip != nil {
+		return ip.String()
+	}
+
+	tldPlusOne, err := tld.EffectiveTLDPlusOne(hostname)
+	if err != nil {
+		return strings.Split(hostname, ".")[0]
+	}
+
+	suffix, _ := tld.PublicSuffix(tldPlusOne)
+	return tldPlusOne[:len(tldPlusOne)-len(suffix)-1]
+}